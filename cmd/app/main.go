@@ -1,33 +1,130 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
 	"github.com/DavidAfdal/workfinder/config"
 	"github.com/DavidAfdal/workfinder/internal/builder"
 	"github.com/DavidAfdal/workfinder/pkg/cache"
+	"github.com/DavidAfdal/workfinder/pkg/encrypt"
+	"github.com/DavidAfdal/workfinder/pkg/health"
+	"github.com/DavidAfdal/workfinder/pkg/logger"
+	"github.com/DavidAfdal/workfinder/pkg/mailer"
+	"github.com/DavidAfdal/workfinder/pkg/metrics"
 	"github.com/DavidAfdal/workfinder/pkg/postgres"
 	"github.com/DavidAfdal/workfinder/pkg/server"
 	"github.com/DavidAfdal/workfinder/pkg/token"
+	"github.com/DavidAfdal/workfinder/pkg/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
 )
 
 func main() {
 	cfg, err := config.NewConfig(".env")
 	checkError(err)
-	db, err := postgres.InitPostgres(&cfg.Postgres)
+
+	// the standard log package writes through it as well
+	appLogger := logger.NewLogger(cfg.Env, os.Stdout)
+	slog.SetDefault(appLogger)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		checkError(runMigrate(&cfg.Postgres, os.Args[2:]))
+		return
+	}
+
+	if cfg.Postgres.AutoMigrate {
+		checkError(autoMigrate(&cfg.Postgres))
+	}
+
+	db, err := postgres.InitPostgres(&cfg.Postgres, appLogger)
+	checkError(err)
+
+	appMetrics := metrics.NewMetrics(prometheus.NewRegistry())
+	checkError(db.Use(metrics.NewGormPlugin(appMetrics)))
+
+	keyring, err := buildKeyring(&cfg.Encrypt)
+	checkError(err)
+	encrypt.RegisterSerializer(keyring)
+	blindIndex, err := encrypt.NewBlindIndex(cfg.Encrypt.BlindIndexKey)
+	checkError(err)
+
+	if len(os.Args) > 1 && os.Args[1] == "schema-check" {
+		checkError(checkSchema(db))
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "rotate-keys" {
+		checkError(rotateKeys(db, keyring, blindIndex, os.Args[2:]))
+		return
+	}
+
+	shutdownTracing, err := tracing.InitTracing(context.Background(), &cfg.Tracing)
+	checkError(err)
+	checkError(db.Use(tracing.NewGormPlugin(otel.GetTracerProvider())))
+
+	keySet, err := buildKeySet(&cfg.JWT)
 	checkError(err)
 
-	tokenUseCase := token.NewTokenUseCase(cfg.JWT.SecretKey)
-	redisDB := cache.InitCache(&cfg.Redis)
+	tokenUseCase := token.NewTokenUseCase(keySet, cfg.JWT.AccessTokenExpire, cfg.JWT.RefreshTokenExpire)
+	redisDB, err := cache.InitCache(&cfg.Redis)
+	checkError(err)
+	cacheable, err := cache.NewCache(&cfg.Cache, redisDB, cfg.Cache.Version)
+	checkError(err)
+	cacheable = cache.NewMeteredCacheable(cacheable, "app", appMetrics.CacheRequests)
+	loader := cache.NewLoader(cacheable, cfg.Cache.StaleWindow, cfg.Cache.ExpireJitter)
+	// the denylist must survive a cache version bump, so it is kept out of the versioned cache
+	denylistCache, err := cache.NewCache(&cfg.Cache, redisDB, "auth")
+	checkError(err)
+	denylist := token.NewDenylist(cache.NewMeteredCacheable(denylistCache, "auth", appMetrics.CacheRequests))
 
-	publicRoutes := builder.BuildAppRoutes(db, tokenUseCase, redisDB)
-	privateRoutes := builder.BuildPrivateAppRoutes(db, redisDB)
+	mail, err := mailer.NewMailer(cfg.Env, &cfg.Mail)
+	checkError(err)
+
+	publicRoutes := builder.BuildAppRoutes(db, tokenUseCase, denylist, mail, cfg, cacheable, loader, blindIndex, appMetrics)
+	privateRoutes := builder.BuildPrivateAppRoutes(db, tokenUseCase, denylist, mail, cfg, cacheable, loader, blindIndex, appMetrics)
 
 
-	srv:= server.NewServer("api", publicRoutes, privateRoutes, cfg.JWT.SecretKey)
+	checks := []health.Check{health.Postgres(db)}
+	if cfg.Cache.Driver != "memory" {
+		checks = append(checks, health.Redis(redisDB))
+	}
+	checker := health.NewChecker(cfg.Server.HealthCheckTimeout, checks...)
+
+	srv:= server.NewServer("api", &cfg.Server, publicRoutes, privateRoutes, keySet, denylist, checker, appMetrics)
 
-	srv.Run()
+	err = srv.Run()
 
+	// the spans of the last requests are still buffered
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	checkError(errors.Join(err, shutdownTracing(ctx)))
 }
 
+func buildKeySet(cfg *config.JwtConfig) (token.KeySet, error) {
+	if cfg.KeysDir == "" {
+		return token.NewHMACKeySet(cfg.SecretKey), nil
+	}
+
+	return token.LoadKeySet(cfg.KeysDir, cfg.SigningKeyID)
+}
+
+func buildKeyring(cfg *config.EncryptConfig) (encrypt.Keyring, error) {
+	keys := map[string]string{cfg.KeyVersion: cfg.SecretKey}
+
+	for version, key := range cfg.DecryptionKeys {
+		if version == cfg.KeyVersion {
+			return nil, fmt.Errorf("encryption key version %q is both primary and a decryption key", version)
+		}
+		keys[version] = key
+	}
+
+	return encrypt.NewKeyring(cfg.KeyVersion, keys)
+}
 
 func checkError(err error) {
 	if err != nil {
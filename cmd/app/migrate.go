@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DavidAfdal/workfinder/config"
+	"github.com/DavidAfdal/workfinder/db"
+	"github.com/DavidAfdal/workfinder/pkg/postgres"
+)
+
+const migrateUsage = "usage: app migrate up | down [steps] | status | force <version>"
+
+// runMigrate runs "app migrate up|down|status|force" against the embedded migrations.
+func runMigrate(cfg *config.PostgresConfig, args []string) (err error) {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := postgres.NewMigrator(cfg, db.Migrations)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, migrator.Close())
+	}()
+
+	switch args[0] {
+	case "up":
+		return migrator.Up()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		return migrator.Down(steps)
+	case "force":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseUint(args[1], 10, 0)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return migrator.Force(uint(version))
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(status)
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// autoMigrate brings the database up to date before the server starts.
+func autoMigrate(cfg *config.PostgresConfig) (err error) {
+	migrator, err := postgres.NewMigrator(cfg, db.Migrations)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, migrator.Close())
+	}()
+
+	return migrator.Up()
+}
+
+func printMigrationStatus(status *postgres.MigrationStatus) {
+	state := "clean"
+	if status.Dirty {
+		state = "dirty"
+	}
+
+	fmt.Printf("version: %06d (%s)\n", status.Version, state)
+	fmt.Printf("applied: %s\n", formatVersions(status.Applied))
+	fmt.Printf("pending: %s\n", formatVersions(status.Pending))
+
+	if len(status.Gaps) > 0 {
+		fmt.Printf("not in the numbering, skipped: %s\n", formatVersions(status.Gaps))
+	}
+}
+
+func formatVersions(versions []uint) string {
+	if len(versions) == 0 {
+		return "-"
+	}
+
+	formatted := make([]string, len(versions))
+	for i, version := range versions {
+		formatted[i] = fmt.Sprintf("%06d", version)
+	}
+
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/DavidAfdal/workfinder/internal/repository"
+	"github.com/DavidAfdal/workfinder/internal/service"
+	"github.com/DavidAfdal/workfinder/pkg/encrypt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// rotateKeys re-encrypts user PII under ENCRYPT_KEY_VERSION. Rotating without downtime takes three steps:
+//
+//  1. deploy the new key in ENCRYPT_DECRYPTION_KEYS, so every instance can read values sealed under it
+//  2. deploy it as ENCRYPT_SECRET_KEY/ENCRYPT_KEY_VERSION, moving the old key to ENCRYPT_DECRYPTION_KEYS
+//  3. run "app rotate-keys", then drop the old key once no value is sealed under it
+//
+// Values sealed before encryption was bound to the row are bound by the same run.
+// The last rotated id is written to the checkpoint file after every batch, an interrupted run resumes from it.
+func rotateKeys(db *gorm.DB, keyring encrypt.Keyring, blindIndex encrypt.BlindIndex, args []string) error {
+	flags := flag.NewFlagSet("rotate-keys", flag.ExitOnError)
+	batchSize := flags.Int("batch", 500, "users re-encrypted per batch")
+	checkpoint := flags.String("checkpoint", "rotate-keys.checkpoint", "file keeping the last rotated user id")
+	flags.Parse(args)
+
+	if *batchSize <= 0 {
+		return errors.New("batch must be positive")
+	}
+
+	afterID, err := readCheckpoint(*checkpoint)
+	if err != nil {
+		return err
+	}
+
+	if afterID != uuid.Nil {
+		log.Printf("rotate-keys: resuming after user %s", afterID)
+	}
+
+	// SIGINT or SIGTERM stops the run, the batch in flight is redone on resume
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rotation := service.NewKeyRotationService(repository.NewUserPIIRepository(db), keyring, blindIndex)
+
+	result, err := rotation.RotateUserPII(ctx, afterID, *batchSize, func(progress *service.KeyRotationProgress) error {
+		log.Printf("rotate-keys: scanned %d, rotated %d, skipped %d, failed %d, last user %s",
+			progress.Scanned, progress.Rotated, progress.Skipped, progress.Failed, progress.LastID)
+
+		return os.WriteFile(*checkpoint, []byte(progress.LastID.String()), 0o600)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("rotate-keys: done under key version %s, scanned %d, rotated %d, skipped %d, failed %d",
+		keyring.Primary(), result.Scanned, result.Rotated, result.Skipped, result.Failed)
+
+	// the run is complete, a rerun starts from the beginning and only touches what is left
+	if err := os.Remove(*checkpoint); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if result.Failed > 0 {
+		return fmt.Errorf("%d users couldn't be decrypted, add their key to ENCRYPT_DECRYPTION_KEYS and run again", result.Failed)
+	}
+
+	return nil
+}
+
+func readCheckpoint(path string) (uuid.UUID, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return uuid.Nil, nil
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.Parse(strings.TrimSpace(string(data)))
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/pkg/postgres"
+	"gorm.io/gorm"
+)
+
+// checkSchema runs "app schema-check", it fails when an entity drifted from the migrated database.
+func checkSchema(db *gorm.DB) error {
+	drifts, err := postgres.CheckSchemaDrift(db, entity.Models()...)
+	if err != nil {
+		return err
+	}
+
+	for _, drift := range drifts {
+		log.Printf("schema-check: %s", drift)
+	}
+
+	if len(drifts) > 0 {
+		return fmt.Errorf("%d schema drifts between the entities and the database", len(drifts))
+	}
+
+	log.Printf("schema-check: %d entities match the database", len(entity.Models()))
+
+	return nil
+}
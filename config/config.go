@@ -2,6 +2,8 @@ package config
 
 import (
 	"errors"
+	"os"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
@@ -9,17 +11,77 @@ import (
 
 
 type Config struct {
-	Env      string         `env:"ENV" envDefault:"dev"`
-	Port     string         `env:"PORT" envDefault:"8080"`
-	Postgres PostgresConfig `envPrefix:"POSTGRES_"`
-	JWT      JwtConfig      `envPrefix:"JWT_"`
-	Redis    RedisConfig    `envPrefix:"REDIS_"`
-	Encrypt  EncryptConfig  `envPrefix:"ENCRYPT_"`
+	Env         string            `env:"ENV" envDefault:"dev"`
+	Server      ServerConfig      `envPrefix:"SERVER_"`
+	Postgres    PostgresConfig    `envPrefix:"POSTGRES_"`
+	JWT         JwtConfig         `envPrefix:"JWT_"`
+	Redis       RedisConfig       `envPrefix:"REDIS_"`
+	Cache       CacheConfig       `envPrefix:"CACHE_"`
+	Encrypt     EncryptConfig     `envPrefix:"ENCRYPT_"`
+	Mail        MailConfig        `envPrefix:"MAIL_"`
+	Application ApplicationConfig `envPrefix:"APPLICATION_"`
+	Tracing     TracingConfig     `envPrefix:"TRACING_"`
 }
 
+// ServerConfig configures the HTTP server, BodyLimit uses the size format of echo's BodyLimit middleware
+// (e.g. "2M"). Port falls back to PORT, which deployments set before SERVER_PORT existed, and then to 8080.
+// TLS is enabled when both TLSCertFile and TLSKeyFile are set, setting only one fails startup. On SIGINT or
+// SIGTERM /readyz fails for ShutdownDelay before the listener closes, then ShutdownTimeout bounds how long
+// in-flight requests are drained. HealthCheckTimeout bounds each dependency ping of /readyz. RequestTimeout is the
+// deadline of every request context, 0 disables it.
+type ServerConfig struct {
+	Port               string        `env:"PORT"`
+	ReadTimeout        time.Duration `env:"READ_TIMEOUT" envDefault:"15s"`
+	ReadHeaderTimeout  time.Duration `env:"READ_HEADER_TIMEOUT" envDefault:"5s"`
+	WriteTimeout       time.Duration `env:"WRITE_TIMEOUT" envDefault:"30s"`
+	IdleTimeout        time.Duration `env:"IDLE_TIMEOUT" envDefault:"120s"`
+	ShutdownTimeout    time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"30s"`
+	ShutdownDelay      time.Duration `env:"SHUTDOWN_DELAY" envDefault:"5s"`
+	HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"2s"`
+	RequestTimeout     time.Duration `env:"REQUEST_TIMEOUT" envDefault:"10s"`
+	BodyLimit          string        `env:"BODY_LIMIT" envDefault:"2M"`
+	TLSCertFile        string        `env:"TLS_CERT_FILE"`
+	TLSKeyFile         string        `env:"TLS_KEY_FILE"`
+}
+
+// TracingConfig picks where spans go, Exporter is one of none, stdout or otlp. OTLPEndpoint is the host:port
+// of an OTLP/HTTP collector, OTLPInsecure sends to it without TLS. SampleRatio is the fraction of new traces
+// recorded, a request whose caller already sampled its trace is always recorded.
+type TracingConfig struct {
+	Exporter     string  `env:"EXPORTER" envDefault:"none"`
+	ServiceName  string  `env:"SERVICE_NAME" envDefault:"workfinder"`
+	OTLPEndpoint string  `env:"OTLP_ENDPOINT" envDefault:"localhost:4318"`
+	OTLPInsecure bool    `env:"OTLP_INSECURE" envDefault:"false"`
+	SampleRatio  float64 `env:"SAMPLE_RATIO" envDefault:"1"`
+}
+
+// ApplicationConfig decides whether an applicant may apply again to a job after withdrawing,
+// and how long they have to wait before doing so.
+type ApplicationConfig struct {
+	AllowReapply    bool          `env:"ALLOW_REAPPLY" envDefault:"true"`
+	ReapplyCooldown time.Duration `env:"REAPPLY_COOLDOWN" envDefault:"0s"`
+}
+
+type MailConfig struct {
+	Driver   string `env:"DRIVER"`
+	Host     string `env:"HOST" envDefault:"localhost"`
+	Port     string `env:"PORT" envDefault:"587"`
+	Username string `env:"USERNAME"`
+	Password string `env:"PASSWORD"`
+	From     string `env:"FROM" envDefault:"WorkFinder <no-reply@workfinder.id>"`
+	FilePath string `env:"FILE_PATH"`
+	BaseURL  string `env:"BASE_URL" envDefault:"http://localhost:8080"`
+}
+
+// EncryptConfig holds the AES key used for user PII, KeyVersion is written next to every ciphertext.
+// DecryptionKeys are older or upcoming keys as "version:key,version:key", they are only used to decrypt.
+// BlindIndexKey must differ from SecretKey, it keys the HMAC that lets encrypted columns be searched.
 type EncryptConfig struct {
-	SecretKey string `env:"SECRET_KEY"`
-	IV        string `env:"IV"`
+	SecretKey      string            `env:"SECRET_KEY"`
+	IV             string            `env:"IV"`
+	KeyVersion     string            `env:"KEY_VERSION" envDefault:"1"`
+	DecryptionKeys map[string]string `env:"DECRYPTION_KEYS"`
+	BlindIndexKey  string            `env:"BLIND_INDEX_KEY"`
 }
 
 type RedisConfig struct {
@@ -28,16 +90,39 @@ type RedisConfig struct {
 	Password string `env:"PASSWORD" envDefault:""`
 }
 
+// CacheConfig selects the cache backend, Driver is one of redis, memory or layered.
+// Size bounds the in-memory LRU and LocalExpire how long the layered mode keeps a local copy.
+// StaleWindow is how long an expired value is still served while it is refreshed, and ExpireJitter
+// the fraction every expiry is randomly spread by.
+type CacheConfig struct {
+	Driver       string        `env:"DRIVER" envDefault:"redis"`
+	Namespace    string        `env:"NAMESPACE" envDefault:"workfinder"`
+	Version      string        `env:"VERSION" envDefault:"v1"`
+	Size         int           `env:"SIZE" envDefault:"10000"`
+	LocalExpire  time.Duration `env:"LOCAL_EXPIRE" envDefault:"10s"`
+	StaleWindow  time.Duration `env:"STALE_WINDOW" envDefault:"30s"`
+	ExpireJitter float64       `env:"EXPIRE_JITTER" envDefault:"0.1"`
+}
+
+// PostgresConfig also decides whether the server migrates the database on startup, replicas wait for each
+// other for at most MigrateLockTimeout.
 type PostgresConfig struct {
-	Host     string `env:"HOST" envDefault:"localhost"`
-	Port     string `env:"PORT" envDefault:"5432"`
-	User     string `env:"USER" envDefault:"postgres"`
-	Password string `env:"PASSWORD" envDefault:"postgres"`
-	Database string `env:"DATABASE" envDefault:"postgres"`
+	Host               string        `env:"HOST" envDefault:"localhost"`
+	Port               string        `env:"PORT" envDefault:"5432"`
+	User               string        `env:"USER" envDefault:"postgres"`
+	Password           string        `env:"PASSWORD" envDefault:"postgres"`
+	Database           string        `env:"DATABASE" envDefault:"postgres"`
+	SSLMode            string        `env:"SSL_MODE" envDefault:"disable"`
+	AutoMigrate        bool          `env:"AUTO_MIGRATE" envDefault:"false"`
+	MigrateLockTimeout time.Duration `env:"MIGRATE_LOCK_TIMEOUT" envDefault:"5m"`
 }
 
 type JwtConfig struct {
-	SecretKey string `env:"SECRET_KEY"`
+	SecretKey          string        `env:"SECRET_KEY"`
+	KeysDir            string        `env:"KEYS_DIR"`
+	SigningKeyID       string        `env:"SIGNING_KEY_ID"`
+	AccessTokenExpire  time.Duration `env:"ACCESS_TOKEN_EXPIRE" envDefault:"5m"`
+	RefreshTokenExpire time.Duration `env:"REFRESH_TOKEN_EXPIRE" envDefault:"720h"`
 }
 
 func NewConfig(envPath string) (*Config, error) {
@@ -53,5 +138,12 @@ func NewConfig(envPath string) (*Config, error) {
 		return nil, errors.New("failed to parse config file")
 	}
 
+	if cfg.Server.Port == "" {
+		cfg.Server.Port = os.Getenv("PORT")
+	}
+	if cfg.Server.Port == "" {
+		cfg.Server.Port = "8080"
+	}
+
 	return cfg, nil
 }
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewConfigServerPort(t *testing.T) {
+	envPath := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envPath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		serverPort string
+		port       string
+		want       string
+	}{
+		{"default", "", "", "8080"},
+		{"PORT of older deployments", "", "9000", "9000"},
+		{"SERVER_PORT wins", "9100", "9000", "9100"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SERVER_PORT", tt.serverPort)
+			t.Setenv("PORT", tt.port)
+			if tt.serverPort == "" {
+				os.Unsetenv("SERVER_PORT")
+			}
+
+			cfg, err := NewConfig(envPath)
+			if err != nil {
+				t.Fatalf("NewConfig() error = %v", err)
+			}
+			if cfg.Server.Port != tt.want {
+				t.Fatalf("Server.Port = %q, want %q", cfg.Server.Port, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package db
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrations holds the files of db/migrations at its root, named NNNNNN_name.up.sql and NNNNNN_name.down.sql.
+var Migrations, _ = fs.Sub(migrations, "migrations")
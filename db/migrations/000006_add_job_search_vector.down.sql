@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_jobs_search_vector;
+ALTER TABLE jobs DROP COLUMN IF EXISTS search_vector;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS application_status_history;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+CREATE TABLE IF NOT EXISTS application_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_applicant_id UUID NOT NULL REFERENCES job_applicants(id) ON DELETE CASCADE,
+    from_status VARCHAR(50) NOT NULL DEFAULT '',
+    to_status VARCHAR(50) NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_status_history_job_applicant_id ON application_status_history (job_applicant_id, created_at);
+
+COMMIT;
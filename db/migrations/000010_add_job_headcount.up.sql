@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS headcount INTEGER NOT NULL DEFAULT 1 CHECK (headcount > 0);
+
+COMMIT;
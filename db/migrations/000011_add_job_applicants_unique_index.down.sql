@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS uq_job_applicants_job_id_applicant_id;
+
+COMMIT;
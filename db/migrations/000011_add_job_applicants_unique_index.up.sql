@@ -0,0 +1,17 @@
+BEGIN;
+
+-- keep the oldest active application when the same user applied to a job more than once
+UPDATE job_applicants duplicate
+SET deleted_at = now()
+FROM job_applicants original
+WHERE duplicate.job_id = original.job_id
+    AND duplicate.applicant_id = original.applicant_id
+    AND duplicate.deleted_at IS NULL
+    AND original.deleted_at IS NULL
+    AND (original.created_at, original.id) < (duplicate.created_at, duplicate.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_job_applicants_job_id_applicant_id
+    ON job_applicants (job_id, applicant_id)
+    WHERE deleted_at IS NULL;
+
+COMMIT;
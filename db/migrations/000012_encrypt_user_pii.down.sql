@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_users_phone_number_index;
+
+ALTER TABLE users DROP COLUMN IF EXISTS phone_number_index;
+
+-- fails while encrypted values are stored, decrypt them before rolling back
+ALTER TABLE users ALTER COLUMN phone_number TYPE VARCHAR(15);
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+-- address and phone_number now hold AES-GCM ciphertext, which is longer than any plaintext phone number
+ALTER TABLE users ALTER COLUMN phone_number TYPE TEXT;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_number_index VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_users_phone_number_index ON users (phone_number_index) WHERE deleted_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- hired applications keep "Hired", the statuses other than "Approved" didn't exist before either
+ALTER TABLE job_applicants DROP CONSTRAINT IF EXISTS chk_job_applicants_status;
+
+COMMIT;
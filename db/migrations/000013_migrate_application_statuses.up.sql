@@ -0,0 +1,23 @@
+BEGIN;
+
+-- applications could be created with any status and used to be "Approved" when hired, the application
+-- workflow calls that "Hired". Known names are matched whatever their case or spacing, anything else is
+-- treated as an application nobody looked at yet.
+UPDATE job_applicants SET status = CASE lower(trim(status))
+        WHEN 'approved'    THEN 'Hired'
+        WHEN 'waiting'     THEN 'Waiting'
+        WHEN 'reviewed'    THEN 'Reviewed'
+        WHEN 'shortlisted' THEN 'Shortlisted'
+        WHEN 'interview'   THEN 'Interview'
+        WHEN 'offered'     THEN 'Offered'
+        WHEN 'hired'       THEN 'Hired'
+        WHEN 'rejected'    THEN 'Rejected'
+        WHEN 'withdrawn'   THEN 'Withdrawn'
+        ELSE 'Waiting'
+    END
+WHERE status NOT IN ('Waiting', 'Reviewed', 'Shortlisted', 'Interview', 'Offered', 'Hired', 'Rejected', 'Withdrawn');
+
+ALTER TABLE job_applicants ADD CONSTRAINT chk_job_applicants_status
+    CHECK (status IN ('Waiting', 'Reviewed', 'Shortlisted', 'Interview', 'Offered', 'Hired', 'Rejected', 'Withdrawn'));
+
+COMMIT;
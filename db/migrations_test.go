@@ -0,0 +1,83 @@
+package db
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DavidAfdal/workfinder/config"
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/pkg/encrypt"
+	"github.com/DavidAfdal/workfinder/pkg/postgres"
+	"github.com/caarlos0/env/v11"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	versions, err := postgres.MigrationVersions(Migrations)
+	if err != nil {
+		t.Fatalf("MigrationVersions() error = %v", err)
+	}
+
+	if len(versions) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	files, _ := fs.Glob(Migrations, "*.sql")
+
+	for _, name := range files {
+		data, err := fs.ReadFile(Migrations, name)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", name, err)
+		}
+
+		sql := strings.TrimSpace(string(data))
+		if !strings.HasPrefix(sql, "BEGIN;") || !strings.HasSuffix(sql, "COMMIT;") {
+			t.Errorf("%s isn't wrapped in BEGIN; ... COMMIT;", name)
+		}
+	}
+}
+
+// TestEntitiesMatchMigratedSchema migrates a disposable database configured through TEST_POSTGRES_* and checks
+// every entity against it, it is skipped when TEST_POSTGRES_HOST isn't set.
+func TestEntitiesMatchMigratedSchema(t *testing.T) {
+	if os.Getenv("TEST_POSTGRES_HOST") == "" {
+		t.Skip("TEST_POSTGRES_HOST isn't set")
+	}
+
+	cfg := new(config.PostgresConfig)
+	if err := env.ParseWithOptions(cfg, env.Options{Prefix: "TEST_POSTGRES_"}); err != nil {
+		t.Fatalf("env.Parse() error = %v", err)
+	}
+
+	migrator, err := postgres.NewMigrator(cfg, Migrations)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	defer migrator.Close()
+
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	keyring, err := encrypt.NewKeyring("1", map[string]string{"1": "0123456789abcdef0123456789abcdef"})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	encrypt.RegisterSerializer(keyring)
+
+	db, err := postgres.InitPostgres(cfg, slog.Default())
+	if err != nil {
+		t.Fatalf("InitPostgres() error = %v", err)
+	}
+
+	drifts, err := postgres.CheckSchemaDrift(db, entity.Models()...)
+	if err != nil {
+		t.Fatalf("CheckSchemaDrift() error = %v", err)
+	}
+
+	for _, drift := range drifts {
+		t.Error(drift)
+	}
+}
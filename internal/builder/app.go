@@ -1,25 +1,31 @@
 package builder
 
 import (
+	"github.com/DavidAfdal/workfinder/config"
 	"github.com/DavidAfdal/workfinder/internal/http/handler"
 	"github.com/DavidAfdal/workfinder/internal/http/router"
 	"github.com/DavidAfdal/workfinder/internal/repository"
 	"github.com/DavidAfdal/workfinder/internal/service"
 	"github.com/DavidAfdal/workfinder/pkg/cache"
+	"github.com/DavidAfdal/workfinder/pkg/encrypt"
+	"github.com/DavidAfdal/workfinder/pkg/mailer"
+	"github.com/DavidAfdal/workfinder/pkg/metrics"
 	"github.com/DavidAfdal/workfinder/pkg/route"
 	"github.com/DavidAfdal/workfinder/pkg/token"
-	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
-func BuildAppRoutes(db *gorm.DB, token token.TokenUseCase, redis *redis.Client ) []*route.Route {
-	cahceable := cache.NewCacheable(redis)
-	userRepository := repository.NewUserRepository(db, cahceable)
-	userService := service.NewUserService(userRepository, token)
-	userHandler := handler.NewUserHandler(userService)
-
-	jobRepository := repository.NewJobRepository(db, cahceable)
-	jobService := service.NewJobService(jobRepository)
+func BuildAppRoutes(db *gorm.DB, tokenUseCase token.TokenUseCase, denylist token.Denylist, mailer mailer.Mailer, cfg *config.Config, cahceable cache.Cacheable, loader cache.Loader, blindIndex encrypt.BlindIndex, metrics *metrics.Metrics) []*route.Route {
+	policy := service.NewPolicy()
+	userRepository := repository.NewUserRepository(db, blindIndex)
+	refreshTokenRepository := repository.NewRefreshTokenRepository(db)
+	userService := service.NewUserService(userRepository, refreshTokenRepository, tokenUseCase, denylist, policy)
+	userTokenRepository := repository.NewUserTokenRepository(db)
+	accountService := service.NewAccountService(userRepository, userTokenRepository, mailer, cfg.Mail.BaseURL)
+	userHandler := handler.NewUserHandler(userService, accountService)
+
+	jobRepository := repository.NewJobRepository(db, cahceable, loader)
+	jobService := service.NewJobService(jobRepository, userRepository, policy, metrics)
 	jobHandler := handler.NewJobHandler(jobService)
 
 	categoryRepo := repository.NewCategoryRepository(db)
@@ -29,19 +35,22 @@ func BuildAppRoutes(db *gorm.DB, token token.TokenUseCase, redis *redis.Client )
 	return router.AppPublicRoutes(userHandler, jobHandler, categoryHandler)
 }
 
-func BuildPrivateAppRoutes(db *gorm.DB, redis *redis.Client) []*route.Route {
-	cahceable := cache.NewCacheable(redis)
-	userRepository := repository.NewUserRepository(db, cahceable)
-	userService := service.NewUserService(userRepository, nil)
-	userHandler := handler.NewUserHandler(userService)
+func BuildPrivateAppRoutes(db *gorm.DB, tokenUseCase token.TokenUseCase, denylist token.Denylist, mailer mailer.Mailer, cfg *config.Config, cahceable cache.Cacheable, loader cache.Loader, blindIndex encrypt.BlindIndex, metrics *metrics.Metrics) []*route.Route {
+	policy := service.NewPolicy()
+	userRepository := repository.NewUserRepository(db, blindIndex)
+	refreshTokenRepository := repository.NewRefreshTokenRepository(db)
+	userService := service.NewUserService(userRepository, refreshTokenRepository, tokenUseCase, denylist, policy)
+	userTokenRepository := repository.NewUserTokenRepository(db)
+	accountService := service.NewAccountService(userRepository, userTokenRepository, mailer, cfg.Mail.BaseURL)
+	userHandler := handler.NewUserHandler(userService, accountService)
 
 
-	jobRepository := repository.NewJobRepository(db, cahceable)
-	jobService := service.NewJobService(jobRepository)
+	jobRepository := repository.NewJobRepository(db, cahceable, loader)
+	jobService := service.NewJobService(jobRepository, userRepository, policy, metrics)
 	jobHandler := handler.NewJobHandler(jobService)
 
-	jobApplicantsRepo := repository.NewJobApplicantsRepository(db)
-	jobApplicantsService := service.NewJobApplicantService(jobApplicantsRepo, jobRepository)
+	jobApplicantsRepo := repository.NewJobApplicantsRepository(db, cahceable)
+	jobApplicantsService := service.NewJobApplicantService(jobApplicantsRepo, jobRepository, policy, service.NewReapplyPolicy(cfg.Application.AllowReapply, cfg.Application.ReapplyCooldown), metrics)
 	jobApplicantHandler := handler.NewJobApplicantsHandler(jobApplicantsService)
 
 	categoryRepo := repository.NewCategoryRepository(db)
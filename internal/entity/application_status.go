@@ -0,0 +1,92 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ApplicationWaiting     = "Waiting"
+	ApplicationReviewed    = "Reviewed"
+	ApplicationShortlisted = "Shortlisted"
+	ApplicationInterview   = "Interview"
+	ApplicationOffered     = "Offered"
+	ApplicationHired       = "Hired"
+	ApplicationRejected    = "Rejected"
+	ApplicationWithdrawn   = "Withdrawn"
+)
+
+// finalApplicationStatuses can't be left anymore.
+var finalApplicationStatuses = []string{ApplicationHired, ApplicationRejected, ApplicationWithdrawn}
+
+// applicationTransitions lists the statuses an application can move to from each status that isn't final.
+var applicationTransitions = map[string][]string{
+	ApplicationWaiting:     {ApplicationReviewed, ApplicationRejected, ApplicationWithdrawn},
+	ApplicationReviewed:    {ApplicationShortlisted, ApplicationRejected, ApplicationWithdrawn},
+	ApplicationShortlisted: {ApplicationInterview, ApplicationRejected, ApplicationWithdrawn},
+	ApplicationInterview:   {ApplicationOffered, ApplicationRejected, ApplicationWithdrawn},
+	ApplicationOffered:     {ApplicationHired, ApplicationRejected, ApplicationWithdrawn},
+}
+
+func CanTransitionApplication(from string, to string) bool {
+	for _, status := range applicationTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsApplicationStatus reports whether status is part of the application workflow.
+func IsApplicationStatus(status string) bool {
+	_, ok := applicationTransitions[status]
+	return ok || IsFinalApplicationStatus(status)
+}
+
+// IsFinalApplicationStatus reports whether an application in status can't move anymore, unknown statuses
+// aren't final.
+func IsFinalApplicationStatus(status string) bool {
+	for _, final := range finalApplicationStatuses {
+		if status == final {
+			return true
+		}
+	}
+	return false
+}
+
+// FinalApplicationStatuses returns a copy of the statuses an application can't leave.
+func FinalApplicationStatuses() []string {
+	return append([]string(nil), finalApplicationStatuses...)
+}
+
+type ApplicationStatusHistory struct {
+	ID             uuid.UUID `json:"id"`
+	JobApplicantID uuid.UUID `json:"-"`
+	FromStatus     string    `json:"from_status"`
+	ToStatus       string    `json:"to_status"`
+	ActorID        uuid.UUID `json:"actor_id"`
+	Reason         string    `json:"reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (ApplicationStatusHistory) TableName() string {
+	return "application_status_history"
+}
+
+func (h *ApplicationStatusHistory) BeforeCreate(tx *gorm.DB) (err error) {
+	h.ID = uuid.New()
+	return
+}
+
+func NewApplicationStatusHistory(jobApplicantID uuid.UUID, fromStatus string, toStatus string, actorID uuid.UUID, reason string) *ApplicationStatusHistory {
+	return &ApplicationStatusHistory{
+		JobApplicantID: jobApplicantID,
+		FromStatus: fromStatus,
+		ToStatus: toStatus,
+		ActorID: actorID,
+		Reason: reason,
+		CreatedAt: time.Now(),
+	}
+}
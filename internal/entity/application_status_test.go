@@ -0,0 +1,27 @@
+package entity
+
+import "testing"
+
+func TestApplicationStatuses(t *testing.T) {
+	for _, tt := range []struct {
+		status    string
+		wantKnown bool
+		wantFinal bool
+	}{
+		{ApplicationWaiting, true, false},
+		{ApplicationOffered, true, false},
+		{ApplicationHired, true, true},
+		{ApplicationRejected, true, true},
+		{ApplicationWithdrawn, true, true},
+		{"Approved", false, false},
+		{"hired", false, false},
+		{"", false, false},
+	} {
+		if got := IsApplicationStatus(tt.status); got != tt.wantKnown {
+			t.Errorf("IsApplicationStatus(%q) = %t, want %t", tt.status, got, tt.wantKnown)
+		}
+		if got := IsFinalApplicationStatus(tt.status); got != tt.wantFinal {
+			t.Errorf("IsFinalApplicationStatus(%q) = %t, want %t", tt.status, got, tt.wantFinal)
+		}
+	}
+}
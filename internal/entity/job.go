@@ -5,7 +5,6 @@ import (
 	"gorm.io/gorm"
 )
 
-
 type Job struct {
 	ID 			uuid.UUID `json:"id"`
 	Title 		string `json:"title"`
@@ -16,8 +15,9 @@ type Job struct {
 	Salary 		float64 `json:"salary,omitempty"`
 	Location 	string `json:"location,omitempty"`
 	Closed    	bool   `json:"closed,omitempty"`
+	Headcount 	int    `json:"headcount,omitempty"`
 	CategoryID  uuid.UUID `json:"-"`
-	ClientID 	uuid.UUID `json:"-"`
+	ClientID 	uuid.UUID `json:"client_id,omitempty"`
 	Category    *Category `json:"category,omitempty"`
 	Client      *User     `json:"client,omitempty" gorm:"foreignKey:client_id"`
 	Applicants []*JobApplicants `json:"applicants,omitempty"`
@@ -30,7 +30,11 @@ func (j *Job) BeforeCreate(tx *gorm.DB) (err error) {
 }
 
 
-func NewJob(title string, description string, company string, logo string, status string, salary float64, location string, categoryID uuid.UUID, clientID uuid.UUID) *Job {
+func NewJob(title string, description string, company string, logo string, status string, salary float64, location string, headcount int, categoryID uuid.UUID, clientID uuid.UUID) *Job {
+	if headcount <= 0 {
+		headcount = 1
+	}
+
 	return &Job{
 		Title: title,
 		Description: description,
@@ -39,6 +43,7 @@ func NewJob(title string, description string, company string, logo string, statu
 		Status: status,
 		Salary: salary,
 		Closed: false,
+		Headcount: headcount,
 		Location: location,
 		CategoryID: categoryID,
 		ClientID: clientID,
@@ -46,7 +51,7 @@ func NewJob(title string, description string, company string, logo string, statu
 	}
 }
 
-func UpdateJob(id uuid.UUID, title string, description string, company string, logo string, status string, salary float64, location string) *Job {
+func UpdateJob(id uuid.UUID, title string, description string, company string, logo string, status string, salary float64, location string, headcount int) *Job {
 	return &Job{
 		ID: id,
 		Title: title,
@@ -56,6 +61,7 @@ func UpdateJob(id uuid.UUID, title string, description string, company string, l
 		Status: status,
 		Salary: salary,
 		Location: location,
+		Headcount: headcount,
 		Audit: UpdateAuditTable(),
 	}
 }
@@ -23,11 +23,11 @@ func (ja *JobApplicants) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
-func NewJobApplicants(jobID uuid.UUID, ApplicantID uuid.UUID, status string, Message string) *JobApplicants {
+func NewJobApplicants(jobID uuid.UUID, ApplicantID uuid.UUID, Message string) *JobApplicants {
 	return &JobApplicants{
 		JobID: jobID,
 		ApplicantID: ApplicantID,
-		Status: status,
+		Status: ApplicationWaiting,
 		Message: Message,
 	}
 }
@@ -0,0 +1,164 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = apperror.Validation("invalid_cursor", "invalid cursor")
+
+const (
+	DefaultJobPageLimit = 20
+	MaxJobPageLimit     = 100
+)
+
+type JobSort string
+
+const (
+	JobSortNewest     JobSort = "newest"
+	JobSortOldest     JobSort = "oldest"
+	JobSortSalaryDesc JobSort = "salary_desc"
+	JobSortSalaryAsc  JobSort = "salary_asc"
+)
+
+type JobFilter struct {
+	CategoryID *uuid.UUID
+	Location   string
+	Status     string
+	MinSalary  float64
+	MaxSalary  float64
+	Closed     *bool
+	Keyword    string
+	Sort       JobSort
+	Cursor     *JobCursor
+	Limit      int
+}
+
+// JobCursor holds the sort keys of the last job of a page, the next page starts right after it.
+type JobCursor struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Salary    float64   `json:"salary"`
+}
+
+type JobPage struct {
+	Jobs       []Job  `json:"jobs"`
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func NewJobFilter(categoryID string, location string, status string, minSalary float64, maxSalary float64, closed string, keyword string, sort string, cursor string, limit int) (*JobFilter, error) {
+	filter := &JobFilter{
+		Location:  strings.ToLower(strings.TrimSpace(location)),
+		Status:    strings.ToLower(strings.TrimSpace(status)),
+		MinSalary: minSalary,
+		MaxSalary: maxSalary,
+		Keyword:   strings.ToLower(strings.TrimSpace(keyword)),
+		Sort:      JobSort(strings.ToLower(strings.TrimSpace(sort))),
+		Limit:     limit,
+	}
+
+	if categoryID = strings.TrimSpace(categoryID); categoryID != "" {
+		id, err := uuid.Parse(categoryID)
+		if err != nil {
+			return nil, apperror.Validation("invalid_filter", "invalid category_id")
+		}
+		filter.CategoryID = &id
+	}
+
+	if closed = strings.TrimSpace(closed); closed != "" {
+		isClosed, err := strconv.ParseBool(closed)
+		if err != nil {
+			return nil, apperror.Validation("invalid_filter", "invalid closed value")
+		}
+		filter.Closed = &isClosed
+	}
+
+	if filter.MinSalary < 0 || filter.MaxSalary < 0 {
+		return nil, apperror.Validation("invalid_filter", "salary range can't be negative")
+	}
+
+	if filter.MaxSalary != 0 && filter.MinSalary > filter.MaxSalary {
+		return nil, apperror.Validation("invalid_filter", "min_salary can't be greater than max_salary")
+	}
+
+	switch filter.Sort {
+	case "":
+		filter.Sort = JobSortNewest
+	case JobSortNewest, JobSortOldest, JobSortSalaryDesc, JobSortSalaryAsc:
+	default:
+		return nil, apperror.Validation("invalid_filter", fmt.Sprintf("invalid sort %q", sort))
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultJobPageLimit
+	}
+	if filter.Limit > MaxJobPageLimit {
+		filter.Limit = MaxJobPageLimit
+	}
+
+	if cursor = strings.TrimSpace(cursor); cursor != "" {
+		decoded, err := DecodeJobCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.Cursor = decoded
+	}
+
+	return filter, nil
+}
+
+// CacheKey builds the cache key from the normalized filter so different queries never share an entry.
+func (f *JobFilter) CacheKey() string {
+	var b strings.Builder
+
+	if f.CategoryID != nil {
+		fmt.Fprintf(&b, "category=%s;", f.CategoryID)
+	}
+	if f.Closed != nil {
+		fmt.Fprintf(&b, "closed=%t;", *f.Closed)
+	}
+	if f.Cursor != nil {
+		fmt.Fprintf(&b, "cursor=%s;", EncodeJobCursor(f.Cursor))
+	}
+	fmt.Fprintf(&b, "keyword=%s;limit=%d;location=%s;", f.Keyword, f.Limit, f.Location)
+	fmt.Fprintf(&b, "max_salary=%g;min_salary=%g;sort=%s;status=%s", f.MaxSalary, f.MinSalary, f.Sort, f.Status)
+
+	return fmt.Sprintf("GetAllJobs:%x", sha256.Sum256([]byte(b.String())))
+}
+
+func NewJobCursor(job *Job) *JobCursor {
+	return &JobCursor{
+		ID:        job.ID,
+		CreatedAt: job.CreatedAt,
+		Salary:    job.Salary,
+	}
+}
+
+func EncodeJobCursor(cursor *JobCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeJobCursor(cursor string) (*JobCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	decoded := new(JobCursor)
+	if err := json.Unmarshal(data, decoded); err != nil || decoded.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,110 @@
+package entity
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+)
+
+type JobSearch struct {
+	Query   string
+	TSQuery string
+	Page    int
+	Limit   int
+}
+
+type JobSearchResult struct {
+	Job
+	Rank                 float64 `json:"rank"`
+	TitleHighlight       string  `json:"title_highlight"`
+	CompanyHighlight     string  `json:"company_highlight"`
+	DescriptionHighlight string  `json:"description_highlight"`
+}
+
+type JobSearchPage struct {
+	Results []JobSearchResult `json:"results"`
+	Total   int64             `json:"total"`
+	Page    int               `json:"page"`
+	Limit   int               `json:"limit"`
+}
+
+func NewJobSearch(query string, page int, limit int) (*JobSearch, error) {
+	tsQuery := BuildTSQuery(query)
+
+	if tsQuery == "" {
+		return nil, apperror.Validation("invalid_search", "search query is required")
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+
+	if limit <= 0 {
+		limit = DefaultJobPageLimit
+	}
+	if limit > MaxJobPageLimit {
+		limit = MaxJobPageLimit
+	}
+
+	return &JobSearch{
+		Query:   strings.TrimSpace(query),
+		TSQuery: tsQuery,
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+func (s *JobSearch) Offset() int {
+	return (s.Page - 1) * s.Limit
+}
+
+// BuildTSQuery turns user input into a to_tsquery expression. Quoted text becomes a phrase (<->),
+// a trailing * becomes a prefix match (:*) and every other term is ANDed together.
+func BuildTSQuery(query string) string {
+	terms := make([]string, 0)
+
+	for i, part := range strings.Split(query, `"`) {
+		words := searchWords(part)
+		if len(words) == 0 {
+			continue
+		}
+
+		// odd parts sit between a pair of quotes
+		if i%2 == 1 {
+			terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+			continue
+		}
+
+		terms = append(terms, words...)
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+func searchWords(text string) []string {
+	words := make([]string, 0)
+
+	for _, field := range strings.Fields(text) {
+		prefix := strings.HasSuffix(field, "*")
+
+		word := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, field)
+
+		if word == "" {
+			continue
+		}
+
+		if prefix {
+			word += ":*"
+		}
+
+		words = append(words, word)
+	}
+
+	return words
+}
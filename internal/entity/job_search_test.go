@@ -0,0 +1,43 @@
+package entity
+
+import "testing"
+
+func TestBuildTSQuery(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"only spaces", "   ", ""},
+		{"only quotes", `""`, ""},
+		{"only operators", `& | ! <-> ( ) : *`, ""},
+		{"single term", "Golang", "golang"},
+		{"terms are ANDed", "golang  backend developer", "golang & backend & developer"},
+		{"operators are stripped", "go & !rust | (java) <-> c:", "go & rust & java & c"},
+		{"punctuation is stripped", "node.js c++ 'admin'", "nodejs & c & admin"},
+		{"sql is just text", "'; DROP TABLE jobs; --", "drop & table & jobs"},
+		{"phrase", `"senior backend"`, "(senior <-> backend)"},
+		{"phrase and terms", `remote "senior backend" go`, "remote & (senior <-> backend) & go"},
+		{"two phrases", `"data engineer" "machine learning"`, "(data <-> engineer) & (machine <-> learning)"},
+		{"single word phrase", `"golang"`, "(golang)"},
+		{"unclosed quote", `go "senior backend`, "go & (senior <-> backend)"},
+		{"prefix", "dev*", "dev:*"},
+		{"prefix in a phrase", `"data sci*"`, "(data <-> sci:*)"},
+		{"prefix needs a word", "* go", "go"},
+		{"star inside a word", "de*v", "dev"},
+		{"unicode letters", "Développeur 日本", "développeur & 日本"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildTSQuery(tt.query); got != tt.want {
+				t.Fatalf("BuildTSQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewJobSearchRequiresQuery(t *testing.T) {
+	if _, err := NewJobSearch(`" & "`, 1, 10); err == nil {
+		t.Fatal("NewJobSearch() with nothing to search error = nil")
+	}
+}
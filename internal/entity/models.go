@@ -0,0 +1,14 @@
+package entity
+
+// Models lists every entity stored in a table of its own, the schema drift check compares them with the database.
+func Models() []interface{} {
+	return []interface{}{
+		&User{},
+		&Category{},
+		&Job{},
+		&JobApplicants{},
+		&ApplicationStatusHistory{},
+		&RefreshToken{},
+		&UserToken{},
+	}
+}
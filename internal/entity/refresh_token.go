@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+
+// RefreshToken belongs to a family that starts at login, every rotation adds a token to the same family.
+type RefreshToken struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"-"`
+	FamilyID     uuid.UUID  `json:"-"`
+	TokenHash    string     `json:"-"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"-"`
+	ReplacedByID *uuid.UUID `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"-"`
+}
+
+func (rt *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if rt.ID == uuid.Nil {
+		rt.ID = uuid.New()
+	}
+	return
+}
+
+func NewRefreshToken(userID uuid.UUID, familyID uuid.UUID, tokenHash string, expiresAt time.Time) *RefreshToken {
+	return &RefreshToken{
+		ID: uuid.New(),
+		UserID: userID,
+		FamilyID: familyID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func (rt *RefreshToken) IsRevoked() bool {
+	return rt.RevokedAt != nil
+}
+
+func (rt *RefreshToken) IsExpired() bool {
+	return time.Now().After(rt.ExpiresAt)
+}
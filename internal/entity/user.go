@@ -1,20 +1,35 @@
 package entity
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+const (
+	RoleAdmin     = "admin"
+	RoleClient    = "client"
+	RoleApplicant = "applicant"
+)
 
+const (
+	GenderMale   = "male"
+	GenderFemale = "female"
+)
 
 type User struct {
 	ID uuid.UUID `json:"id"`
 	Name string `json:"name"`
 	Email string `json:"email,omitempty"`
 	Password string `json:"-"`
-	Address string `json:"address,omitempty"`
-	PhoneNumber string `json:"phone_number,omitempty"`
+	Address string `json:"address,omitempty" gorm:"serializer:encrypted"`
+	PhoneNumber string `json:"phone_number,omitempty" gorm:"serializer:encrypted"`
+	// PhoneNumberIndex is the blind index of PhoneNumber, it is what lookups by phone number compare
+	PhoneNumberIndex string `json:"-"`
 	Gender string `json:"gender,omitempty"`
+	Role string `json:"role,omitempty"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 	Audit
 }
 
@@ -23,7 +38,7 @@ func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
-func NewUser(name string, email string, password string, address string, phoneNumber string, gender string) *User {
+func NewUser(name string, email string, password string, address string, phoneNumber string, gender string, role string) *User {
 	return &User{
 		Name: name,
 		Email: email,
@@ -31,6 +46,7 @@ func NewUser(name string, email string, password string, address string, phoneNu
 		Address: address,
 		PhoneNumber: phoneNumber,
 		Gender: gender,
+		Role: role,
 		Audit: NewAuditTable(),
 	}
 }
@@ -47,3 +63,7 @@ func UpdateUser(id uuid.UUID,name string, email string, password string, address
 		Audit: UpdateAuditTable(),
 	}
 }
+
+func (u *User) IsVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
@@ -0,0 +1,12 @@
+package entity
+
+import "github.com/google/uuid"
+
+// UserPII is the personal data of a user exactly as stored, Address and PhoneNumber may be ciphertext
+// under any key version or plaintext written before encryption was enabled.
+type UserPII struct {
+	ID               uuid.UUID
+	Address          string
+	PhoneNumber      string
+	PhoneNumberIndex string
+}
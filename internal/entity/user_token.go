@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	UserTokenPasswordReset     = "password_reset"
+	UserTokenEmailVerification = "email_verification"
+
+	PasswordResetTokenExpire     = time.Hour
+	EmailVerificationTokenExpire = 24 * time.Hour
+)
+
+
+// UserToken is a single-use token mailed to a user, only its hash is stored.
+type UserToken struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"-"`
+	Purpose   string     `json:"purpose"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"-"`
+}
+
+func (ut *UserToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if ut.ID == uuid.Nil {
+		ut.ID = uuid.New()
+	}
+	return
+}
+
+func NewUserToken(userID uuid.UUID, purpose string, tokenHash string, expire time.Duration) *UserToken {
+	return &UserToken{
+		UserID: userID,
+		Purpose: purpose,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(expire),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
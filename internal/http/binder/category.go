@@ -2,19 +2,19 @@ package binder
 
 
 type CreateCategoryRequest struct {
-	Title string `json:"title"`
-	Icon string `json:"icon"`
+	Title string `json:"title" validate:"required,max=255"`
+	Icon string `json:"icon" validate:"required,max=255"`
 }
 
 type UpdateCategoryRequest struct {
-	ID   string `param:"id"`
-	Title string `json:"title"`
-	Icon string `json:"icon"`
+	ID   string `param:"id" validate:"required,uuid"`
+	Title string `json:"title" validate:"omitempty,max=255"`
+	Icon string `json:"icon" validate:"omitempty,max=255"`
 }
 
 type DeleteCategoryRequest struct {
-	ID   string `param:"id"`
+	ID   string `param:"id" validate:"required,uuid"`
 }
 type FindCategoryByIDRequest struct {
-	ID   string `param:"id"`
+	ID   string `param:"id" validate:"required,uuid"`
 }
@@ -2,37 +2,58 @@ package binder
 
 import "github.com/google/uuid"
 
+type FindJobsRequest struct {
+	CategoryID string  `query:"category_id" validate:"omitempty,uuid"`
+	Location   string  `query:"location" validate:"max=50"`
+	Status     string  `query:"status" validate:"max=255"`
+	MinSalary  float64 `query:"min_salary" validate:"gte=0"`
+	MaxSalary  float64 `query:"max_salary" validate:"gte=0"`
+	Closed     string  `query:"closed" validate:"omitempty,oneof=true false"`
+	Keyword    string  `query:"keyword" validate:"max=255"`
+	Sort       string  `query:"sort" validate:"omitempty,oneof=newest oldest salary_desc salary_asc"`
+	Cursor     string  `query:"cursor" validate:"max=512"`
+	Limit      int     `query:"limit" validate:"gte=0,lte=100"`
+}
+
+type SearchJobsRequest struct {
+	Query string `query:"q" validate:"required,max=255"`
+	Page  int    `query:"page" validate:"gte=0"`
+	Limit int    `query:"limit" validate:"gte=0,lte=100"`
+}
+
 type JobFindByIDRequest struct {
-	ID string `param:"id" validate:"required"`
+	ID string `param:"id" validate:"required,uuid"`
 }
 type DeleteJobRequest struct {
-	ID string `param:"id" validate:"required"`
+	ID string `param:"id" validate:"required,uuid"`
 }
 type FindSharedJobsRequest struct {
-	UserID string `param:"userID" validate:"required"`
+	UserID string `param:"userID" validate:"required,uuid"`
 }
 
 
 type CreateJobRequest struct {
-	Title 		string `json:"title"`
+	Title 		string `json:"title" validate:"required,max=255"`
 	Description string `json:"description"`
-	Company 	string `json:"company"`
-	Logo 		string `json:"logo"`
-	Status 		string `json:"status"`
-	Salary 		float64    `json:"salary"`
-	Location 	string `json:"location"`
-	CategoryID  uuid.UUID `json:"category_id"`
+	Company 	string `json:"company" validate:"required,max=255"`
+	Logo 		string `json:"logo" validate:"required,max=255"`
+	Status 		string `json:"status" validate:"required,max=255"`
+	Salary 		float64    `json:"salary" validate:"gt=0,lte=99999999.99"`
+	Location 	string `json:"location" validate:"required,max=50"`
+	Headcount 	int    `json:"headcount" validate:"gte=0"`
+	CategoryID  uuid.UUID `json:"category_id" validate:"required"`
 }
 
 type UpdateJobRequest struct {
-	ID 			uuid.UUID `param:"id"`
-	Title 		string `json:"title"`
+	ID 			uuid.UUID `param:"id" validate:"required"`
+	Title 		string `json:"title" validate:"max=255"`
 	Description string `json:"description"`
-	Company 	string `json:"company"`
-	Logo 		string `json:"logo"`
-	Status 		string `json:"status"`
-	Salary 		float64    `json:"salary"`
-	Location 	string `json:"location"`
+	Company 	string `json:"company" validate:"max=255"`
+	Logo 		string `json:"logo" validate:"max=255"`
+	Status 		string `json:"status" validate:"max=255"`
+	Salary 		float64    `json:"salary" validate:"omitempty,gt=0,lte=99999999.99"`
+	Location 	string `json:"location" validate:"max=50"`
+	Headcount 	int    `json:"headcount" validate:"gte=0"`
 	CategoryID  uuid.UUID `json:"category_id"`
 	ClientID 	uuid.UUID `json:"client_id"`
 }
@@ -2,19 +2,29 @@ package binder
 
 
 type ApplyJobRequest struct {
-	JobID string `param:"jobID"`
-	Status string `json:"status"`
-	Message string `json:"message"`
+	JobID string `param:"jobID" validate:"required,uuid"`
+	Message string `json:"message" validate:"max=5000"`
+}
+
+type UpdateApplicationStatusRequest struct {
+	JobApplicantID string `param:"jobApplicantID" validate:"required,uuid"`
+	Status string `json:"status" validate:"required,oneof=Reviewed Shortlisted Interview Offered Hired Rejected Withdrawn"`
+	Reason string `json:"reason" validate:"max=1000"`
+}
+
+type FindApplicationHistoryRequest struct {
+	JobApplicantID string `param:"jobApplicantID" validate:"required,uuid"`
 }
 
 type WithdrawJobRequest struct {
-	JobApplicantID string `param:"jobApplicantID"`
+	JobApplicantID string `param:"jobApplicantID" validate:"required,uuid"`
 }
 
 type ApproveApplicantRequest struct {
-	JobApplicantID string `param:"jobApplicantID"`
+	JobApplicantID string `param:"jobApplicantID" validate:"required,uuid"`
+	RejectionMessage string `query:"rejection_message" json:"rejection_message" validate:"max=1000"`
 }
 
 type FindJobApplicantByIDRequest struct {
-	JobApplicantID string `param:"jobApplicantID"`
+	JobApplicantID string `param:"jobApplicantID" validate:"required,uuid"`
 }
@@ -3,28 +3,51 @@ package binder
 // TODO : Create Type Input Request For User Handler
 
 type LoginRequest struct {
-	Email string `json:"email" validate:"email,required"`
-	Password string `json:"password" validate:"required"`
+	Email string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=255"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"max=255"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+type ResetPasswordRequest struct {
+	Token string `json:"token" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required,max=255"`
 }
 
 type CreateUserRequest struct {
-	Name string `json:"name"`
-	Email string `json:"email"`
-	Password string `json:"password"`
-	Address string `json:"address"`
-	PhoneNumber string `json:"phone_number"`
-	Gender string `json:"gender"`
+	Name string `json:"name" validate:"required,max=255"`
+	Email string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,password"`
+	Address string `json:"address" validate:"max=1000"`
+	PhoneNumber string `json:"phone_number" validate:"omitempty,phone"`
+	Gender string `json:"gender" validate:"omitempty,oneof=male female"`
+	Role string `json:"role" validate:"omitempty,oneof=client applicant"`
 }
 
 type UpdateUserRequest struct {
-	Name string `json:"name"`
-	Email string `json:"email"`
-	Password string `json:"password"`
-	Address string `json:"address"`
-	PhoneNumber string `json:"phone_number"`
-	Gender string `json:"gender"`
+	ID string `param:"id" validate:"omitempty,uuid"`
+	Name string `json:"name" validate:"max=255"`
+	Email string `json:"email" validate:"omitempty,email,max=255"`
+	Password string `json:"password" validate:"omitempty,password"`
+	Address string `json:"address" validate:"max=1000"`
+	PhoneNumber string `json:"phone_number" validate:"omitempty,phone"`
+	Gender string `json:"gender" validate:"omitempty,oneof=male female"`
 }
 
 type UserFindByIDRequest struct {
-	ID string `param:"id" validate:"required"`
+	ID string `param:"id" validate:"required,uuid"`
 }
@@ -7,7 +7,6 @@ import (
 	"github.com/DavidAfdal/workfinder/internal/http/binder"
 	"github.com/DavidAfdal/workfinder/internal/service"
 	"github.com/DavidAfdal/workfinder/pkg/response"
-	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -30,10 +29,10 @@ func NewCategoryHandler(categoryService service.CategoryService) CategoryHandler
 }
 
 func (h *categoryHandler) FindAllCategory(ctx echo.Context) error {
-	categories, err := h.categoryService.FindAllCategory()
+	categories, err := h.categoryService.FindAllCategory(ctx.Request().Context())
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success get all categories", categories))
@@ -44,14 +43,22 @@ func (c *categoryHandler) FindCategoryByID(ctx echo.Context)  error {
     var input binder.FindCategoryByIDRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
 	}
 
-    id := uuid.MustParse(input.ID)
-	category, err := c.categoryService.FindCategoryByID(id)
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+    id, err := parseUUID(input.ID, "id")
+
+    if err != nil {
+    	return err
+    }
+	category, err := c.categoryService.FindCategoryByID(ctx.Request().Context(), id)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success get category", category))
@@ -62,15 +69,19 @@ func (c *categoryHandler) CreateCategory(ctx echo.Context) error {
 	var input binder.CreateCategoryRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
 	}
 
 	newCategory := entity.NewCategory(input.Title, input.Icon)
 
-	category, err := c.categoryService.CreateCategory(newCategory)
+	category, err := c.categoryService.CreateCategory(ctx.Request().Context(), newCategory)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success create category", category))
@@ -80,15 +91,23 @@ func (c *categoryHandler) DeleteCategory(ctx echo.Context) error {
 	var input binder.DeleteCategoryRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
 	}
 
-	id := uuid.MustParse(input.ID)
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
 
-	isDeleted, err := c.categoryService.DeleteCategory(id)
+	id, err := parseUUID(input.ID, "id")
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
+	}
+
+	isDeleted, err := c.categoryService.DeleteCategory(ctx.Request().Context(), id)
+
+	if err != nil {
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success delete category", isDeleted))
@@ -99,17 +118,25 @@ func (c *categoryHandler) UpdateCategory(ctx echo.Context) error {
 	var input binder.UpdateCategoryRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
 	}
 
-	id := uuid.MustParse(input.ID)
+	id, err := parseUUID(input.ID, "id")
+
+	if err != nil {
+		return err
+	}
 
 	updateCategory := entity.UpdateCategory(id, input.Title, input.Icon)
 
-	updatedCategory, err := c.categoryService.UpdateCategory(updateCategory)
+	updatedCategory, err := c.categoryService.UpdateCategory(ctx.Request().Context(), updateCategory)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success update category", updatedCategory))
@@ -9,13 +9,13 @@ import (
 	"github.com/DavidAfdal/workfinder/pkg/response"
 	"github.com/DavidAfdal/workfinder/pkg/token"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 
 type JobHandler interface {
     FindJobs(ctx echo.Context) error
+	SearchJobs(ctx echo.Context) error
 	FindJobByID(ctx echo.Context) error
 	CreateJob(ctx echo.Context) error
 	UpdateJob(ctx echo.Context) error
@@ -35,22 +35,74 @@ func NewJobHandler(jobService service.JobService) JobHandler {
 
 
 func (h *jobHandler) FindJobs(ctx echo.Context) error {
-	jobs, err := h.jobService.FindAllJob()
+	var input binder.FindJobsRequest
+
+	if err := ctx.Bind(&input); err != nil {
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	filter, err := entity.NewJobFilter(input.CategoryID, input.Location, input.Status, input.MinSalary, input.MaxSalary, input.Closed, input.Keyword, input.Sort, input.Cursor, input.Limit)
+
+	if err != nil {
+		return err
+	}
+
+	page, err := h.jobService.FindAllJob(ctx.Request().Context(), filter)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, response.PaginatedResponse(http.StatusOK, "Succes get all jobs", page.Jobs, response.Pagination{
+		Total:      page.Total,
+		Limit:      page.Limit,
+		NextCursor: page.NextCursor,
+		HasMore:    page.NextCursor != "",
+	}))
+}
+
+func (h *jobHandler) SearchJobs(ctx echo.Context) error {
+	var input binder.SearchJobsRequest
+
+	if err := ctx.Bind(&input); err != nil {
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	search, err := entity.NewJobSearch(input.Query, input.Page, input.Limit)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
-	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "Succes get all jobs", jobs))
+	page, err := h.jobService.SearchJobs(ctx.Request().Context(), search)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, response.PaginatedResponse(http.StatusOK, "Succes search jobs", page.Results, response.Pagination{
+		Total:   page.Total,
+		Limit:   page.Limit,
+		Page:    page.Page,
+		HasMore: int64(page.Page*page.Limit) < page.Total,
+	}))
 }
 
 func (h *jobHandler) FindSharedJobs(ctx echo.Context) error {
 	dataUser, _ := ctx.Get("user").(*jwt.Token)
 	claims := dataUser.Claims.(*token.JwtCustomClaims)
-	jobs, err := h.jobService.FindSharedJobs(claims.ID)
+	jobs, err := h.jobService.FindSharedJobs(ctx.Request().Context(), claims.ID)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK,response.SuccessResponse(http.StatusOK, "Succes Get Shared Jobs", jobs))
@@ -58,10 +110,10 @@ func (h *jobHandler) FindSharedJobs(ctx echo.Context) error {
 func (h *jobHandler) FindAppliedJobs(ctx echo.Context) error {
 	dataUser, _ := ctx.Get("user").(*jwt.Token)
 	claims := dataUser.Claims.(*token.JwtCustomClaims)
-	jobs, err := h.jobService.FindAppliedJobs(claims.ID)
+	jobs, err := h.jobService.FindAppliedJobs(ctx.Request().Context(), claims.ID)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK,response.SuccessResponse(http.StatusOK, "Succes Get Applied Jobs", jobs))
@@ -71,15 +123,23 @@ func (h *jobHandler) FindJobByID(ctx echo.Context) error {
 	var input binder.JobFindByIDRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
 	}
 
-	id := uuid.MustParse(input.ID)
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
 
-	job, err := h.jobService.FindJobByID(id)
+	id, err := parseUUID(input.ID, "id")
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
+	}
+
+	job, err := h.jobService.FindJobByID(ctx.Request().Context(), id)
+
+	if err != nil {
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "Succes get job details", job))
@@ -93,51 +153,73 @@ func (h * jobHandler) CreateJob(ctx echo.Context) error {
 
 	var input binder.CreateJobRequest
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
 	}
 
-	newJob := entity.NewJob(input.Title, input.Description, input.Company, input.Logo, input.Status, input.Salary, input.Location, input.CategoryID, claims.ID)
+	newJob := entity.NewJob(input.Title, input.Description, input.Company, input.Logo, input.Status, input.Salary, input.Location, input.Headcount, input.CategoryID, claims.ID)
 
-	job, err := h.jobService.CreateJob(newJob)
+	job, err := h.jobService.CreateJob(ctx.Request().Context(), newJob)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success create job", job))
 }
 
 func (h *jobHandler) UpdateJob(ctx echo.Context) error {
+   dataUser, _ := ctx.Get("user").(*jwt.Token)
+   claims := dataUser.Claims.(*token.JwtCustomClaims)
+
    var input binder.UpdateJobRequest
 
    if err := ctx.Bind(&input); err != nil {
-	   return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	   return err
+   }
+
+   if err := ctx.Validate(&input); err != nil {
+	   return err
    }
 
-   updateJob := entity.UpdateJob(input.ID, input.Title, input.Description, input.Company, input.Logo, input.Status, input.Salary, input.Location)
+   updateJob := entity.UpdateJob(input.ID, input.Title, input.Description, input.Company, input.Logo, input.Status, input.Salary, input.Location, input.Headcount)
 
-   updatedJob, err := h.jobService.UpdateJob(updateJob)
+   updatedJob, err := h.jobService.UpdateJob(ctx.Request().Context(), service.NewActor(claims.ID, claims.Role), updateJob)
 
    if err != nil {
-	   return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	   return err
    }
 
    return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success update job", updatedJob))
 }
 
 func (h *jobHandler) DeleteJob(ctx echo.Context) error {
+	dataUser, _ := ctx.Get("user").(*jwt.Token)
+	claims := dataUser.Claims.(*token.JwtCustomClaims)
+
 	var input binder.DeleteJobRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
 	}
 
-	id := uuid.MustParse(input.ID)
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	id, err := parseUUID(input.ID, "id")
+
+	if err != nil {
+		return err
+	}
 
-	isDeleted, err := h.jobService.DeleteJob(id)
+	isDeleted, err := h.jobService.DeleteJob(ctx.Request().Context(), service.NewActor(claims.ID, claims.Role), id)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success delete job", isDeleted))
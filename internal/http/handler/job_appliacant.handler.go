@@ -9,7 +9,6 @@ import (
 	"github.com/DavidAfdal/workfinder/pkg/response"
 	"github.com/DavidAfdal/workfinder/pkg/token"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -19,6 +18,8 @@ type JobApplicantsHandler interface {
 	WithdrawnJobApplicants(ctx echo.Context) error
 	ApproveApplicant(ctx echo.Context) error
 	FindJobApplicantsByID(ctx echo.Context) error
+	UpdateStatus(ctx echo.Context) error
+	FindStatusHistory(ctx echo.Context) error
 }
 
 type jobApplicantsHandler struct {
@@ -38,18 +39,26 @@ func (h *jobApplicantsHandler) ApplyJob(ctx echo.Context) error {
 	var input binder.ApplyJobRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
 	}
 
-	jobID := uuid.MustParse(input.JobID)
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	jobID, err := parseUUID(input.JobID, "job_id")
+
+	if err != nil {
+		return err
+	}
 
-	newJobApplicant := entity.NewJobApplicants(jobID, claims.ID, input.Status, input.Message)
+	newJobApplicant := entity.NewJobApplicants(jobID, claims.ID, input.Message)
 
 
-	_, err := h.jobApplicantsService.ApplyJob(newJobApplicant)
+	_, err = h.jobApplicantsService.ApplyJob(ctx.Request().Context(), newJobApplicant)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 
@@ -63,34 +72,53 @@ func (h *jobApplicantsHandler) WithdrawnJobApplicants(ctx echo.Context) error {
 	var input binder.WithdrawJobRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
 	}
 
 
-	id := uuid.MustParse(input.JobApplicantID)
-	_, err := h.jobApplicantsService.WithdrawJob(id, claims.ID)
+	id, err := parseUUID(input.JobApplicantID, "job_applicant_id")
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
+	}
+
+	_, err = h.jobApplicantsService.WithdrawJob(ctx.Request().Context(), service.NewActor(claims.ID, claims.Role), id)
+
+	if err != nil {
+		return err
 	}
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success withdraw job", nil))
 }
 
 func (h *jobApplicantsHandler) FindJobApplicantsByID(ctx echo.Context) error {
+	dataUser, _ := ctx.Get("user").(*jwt.Token)
+	claims := dataUser.Claims.(*token.JwtCustomClaims)
 
 	var input binder.FindJobApplicantByIDRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
 	}
 
 
-	id := uuid.MustParse(input.JobApplicantID)
+	id, err := parseUUID(input.JobApplicantID, "job_applicant_id")
 
-	jobApplicant, err := h.jobApplicantsService.FindJobApplicantByID(id)
+	if err != nil {
+		return err
+	}
+
+	jobApplicant, err := h.jobApplicantsService.FindJobApplicantByID(ctx.Request().Context(), service.NewActor(claims.ID, claims.Role), id)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success find job applicant", jobApplicant))
@@ -104,18 +132,83 @@ func (h *jobApplicantsHandler) ApproveApplicant(ctx echo.Context) error {
 	var input binder.ApproveApplicantRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
 	}
 
-	id := uuid.MustParse(input.JobApplicantID)
+	id, err := parseUUID(input.JobApplicantID, "job_applicant_id")
 
+	if err != nil {
+		return err
+	}
 
-	_, err := h.jobApplicantsService.ApproveApplicant(id, claims.ID)
+	_, err = h.jobApplicantsService.ApproveApplicant(ctx.Request().Context(), service.NewActor(claims.ID, claims.Role), id, input.RejectionMessage)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success approve job", nil))
 }
 
+func (h *jobApplicantsHandler) UpdateStatus(ctx echo.Context) error {
+	dataUser, _ := ctx.Get("user").(*jwt.Token)
+	claims := dataUser.Claims.(*token.JwtCustomClaims)
+
+	var input binder.UpdateApplicationStatusRequest
+
+	if err := ctx.Bind(&input); err != nil {
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	id, err := parseUUID(input.JobApplicantID, "job_applicant_id")
+
+	if err != nil {
+		return err
+	}
+
+	jobApplicant, err := h.jobApplicantsService.UpdateStatus(ctx.Request().Context(), service.NewActor(claims.ID, claims.Role), id, input.Status, input.Reason)
+
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success update application status", jobApplicant))
+}
+
+func (h *jobApplicantsHandler) FindStatusHistory(ctx echo.Context) error {
+	dataUser, _ := ctx.Get("user").(*jwt.Token)
+	claims := dataUser.Claims.(*token.JwtCustomClaims)
+
+	var input binder.FindApplicationHistoryRequest
+
+	if err := ctx.Bind(&input); err != nil {
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	id, err := parseUUID(input.JobApplicantID, "job_applicant_id")
+
+	if err != nil {
+		return err
+	}
+
+	history, err := h.jobApplicantsService.FindStatusHistory(ctx.Request().Context(), service.NewActor(claims.ID, claims.Role), id)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success get application history", history))
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+	"github.com/google/uuid"
+)
+
+var errInvalidID = apperror.Validation("invalid_id", "invalid id")
+
+// parseUUID parses an id taken from the request, a malformed id is a validation error instead of a panic.
+func parseUUID(value string, field string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, errInvalidID.WithMessage(fmt.Sprintf("invalid %s", field))
+	}
+	return id, nil
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/DavidAfdal/workfinder/internal/entity"
@@ -9,7 +10,6 @@ import (
 	"github.com/DavidAfdal/workfinder/pkg/response"
 	"github.com/DavidAfdal/workfinder/pkg/token"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -18,27 +18,33 @@ type UserHandler interface {
 	FindAllUser(ctx echo.Context) error
 	CreateUser(ctx echo.Context) error
 	Login(ctx echo.Context) error
+	RefreshToken(ctx echo.Context) error
 	UpdateUser(ctx echo.Context) error
 	DeleteUser(ctx echo.Context) error
 	FindByUserID(ctx echo.Context) error
 	ProfileUser(ctx echo.Context) error
 	Logout(ctx echo.Context) error
+	ForgotPassword(ctx echo.Context) error
+	ResetPassword(ctx echo.Context) error
+	RequestEmailVerification(ctx echo.Context) error
+	VerifyEmail(ctx echo.Context) error
 }
 
 type userHandler struct {
    userService service.UserService
+   accountService service.AccountService
 }
 
-func NewUserHandler(userService service.UserService) UserHandler {
-	return &userHandler{userService: userService}
+func NewUserHandler(userService service.UserService, accountService service.AccountService) UserHandler {
+	return &userHandler{userService: userService, accountService: accountService}
 }
 
 func (h *userHandler) FindAllUser(ctx echo.Context) error {
 
-	data, err := h.userService.FindAllUser()
+	data, err := h.userService.FindAllUser(ctx.Request().Context())
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success get all users", data))
 }
@@ -47,32 +53,63 @@ func (h *userHandler) Login(ctx echo.Context) error {
 	var input binder.LoginRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
 	}
 
-	accessToken, err := h.userService.Login(input.Email, input.Password)
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	tokenPair, err := h.userService.Login(ctx.Request().Context(), input.Email, input.Password)
 
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
 	}
 
-	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success login", map[string]interface{}{
-		"access_token": accessToken,
-	}))
+	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success login", tokenPair))
+}
+
+func (h *userHandler) RefreshToken(ctx echo.Context) error {
+	var input binder.RefreshTokenRequest
+
+	if err := ctx.Bind(&input); err != nil {
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	tokenPair, err := h.userService.RefreshToken(ctx.Request().Context(), input.RefreshToken)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success refresh token", tokenPair))
 }
 
 func (h *userHandler) CreateUser(ctx echo.Context) error {
 	var input binder.CreateUserRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
 	}
 
-	newUser := entity.NewUser(input.Name, input.Email, input.Password, input.Address, input.PhoneNumber, input.Gender)
-	user, err := h.userService.CreateUser(newUser)
+	newUser := entity.NewUser(input.Name, input.Email, input.Password, input.Address, input.PhoneNumber, input.Gender, input.Role)
+	user, err := h.userService.CreateUser(ctx.Request().Context(), newUser)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
+	}
+
+	// the account exists already, a failed mail can be resent from /email/verification
+	if err := h.accountService.RequestEmailVerification(ctx.Request().Context(), user.ID); err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "failed to send verification email", "user_id", user.ID, "error", err)
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success create user", user))
@@ -84,15 +121,29 @@ func (h *userHandler) UpdateUser(ctx echo.Context) error {
 
 	var input binder.UpdateUserRequest
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	id := claims.ID
+
+	if input.ID != "" {
+		parsedID, err := parseUUID(input.ID, "user id")
+		if err != nil {
+			return err
+		}
+		id = parsedID
 	}
 
-	updateUser := entity.UpdateUser(claims.ID, input.Name, input.Email, input.Password, input.Address, input.PhoneNumber, input.Gender)
+	updateUser := entity.UpdateUser(id, input.Name, input.Email, input.Password, input.Address, input.PhoneNumber, input.Gender)
 
-	updatedUser, err := h.userService.UpdateUser(updateUser)
+	updatedUser, err := h.userService.UpdateUser(ctx.Request().Context(), service.NewActor(claims.ID, claims.Role), updateUser)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success update user", updatedUser))
@@ -103,10 +154,10 @@ func (h *userHandler) DeleteUser(ctx echo.Context) error {
 	claims := dataUser.Claims.(*token.JwtCustomClaims)
 
 
-	isDeleted, err := h.userService.DeleteUser(claims.ID)
+	isDeleted, err := h.userService.DeleteUser(ctx.Request().Context(), claims.ID)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success delete user", isDeleted))
@@ -117,10 +168,10 @@ func (h *userHandler) ProfileUser(ctx echo.Context) error {
 	claims := dataUser.Claims.(*token.JwtCustomClaims)
 
 
-	user, err := h.userService.FindById(claims.ID)
+	user, err := h.userService.FindById(ctx.Request().Context(), claims.ID)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success get user", user))
@@ -130,21 +181,119 @@ func (h *userHandler) FindByUserID(ctx echo.Context) error {
 	var input binder.UserFindByIDRequest
 
 	if err := ctx.Bind(&input); err != nil {
-		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		return err
 	}
 
-	id := uuid.MustParse(input.ID)
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
 
-	user, err := h.userService.FindById(id)
+	id, err := parseUUID(input.ID, "id")
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		return err
+	}
+
+	user, err := h.userService.FindById(ctx.Request().Context(), id)
+
+	if err != nil {
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success get user", user))
 }
 
 func (h *userHandler) Logout(ctx echo.Context) error {
-	return nil
+	dataUser, _ := ctx.Get("user").(*jwt.Token)
+	claims := dataUser.Claims.(*token.JwtCustomClaims)
+
+	var input binder.LogoutRequest
+
+	if err := ctx.Bind(&input); err != nil {
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	err := h.userService.Logout(ctx.Request().Context(), claims, input.RefreshToken)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success logout", nil))
+}
+
+
+func (h *userHandler) ForgotPassword(ctx echo.Context) error {
+	var input binder.ForgotPasswordRequest
+
+	if err := ctx.Bind(&input); err != nil {
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	if err := h.accountService.RequestPasswordReset(ctx.Request().Context(), input.Email); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "if the email is registered, a reset link has been sent", nil))
+}
+
+func (h *userHandler) ResetPassword(ctx echo.Context) error {
+	var input binder.ResetPasswordRequest
+
+	if err := ctx.Bind(&input); err != nil {
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	err := h.accountService.ResetPassword(ctx.Request().Context(), input.Token, input.Password)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success reset password", nil))
 }
 
+func (h *userHandler) RequestEmailVerification(ctx echo.Context) error {
+	dataUser, _ := ctx.Get("user").(*jwt.Token)
+	claims := dataUser.Claims.(*token.JwtCustomClaims)
+
+	err := h.accountService.RequestEmailVerification(ctx.Request().Context(), claims.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "verification email sent", nil))
+}
+
+func (h *userHandler) VerifyEmail(ctx echo.Context) error {
+	var input binder.VerifyEmailRequest
+
+	if err := ctx.Bind(&input); err != nil {
+		return err
+	}
+
+	if err := ctx.Validate(&input); err != nil {
+		return err
+	}
+
+	err := h.accountService.VerifyEmail(ctx.Request().Context(), input.Token)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "success verify email", nil))
+}
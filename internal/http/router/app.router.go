@@ -3,6 +3,7 @@ package router
 import (
 	"net/http"
 
+	"github.com/DavidAfdal/workfinder/internal/entity"
 	"github.com/DavidAfdal/workfinder/internal/http/handler"
 	"github.com/DavidAfdal/workfinder/pkg/route"
 )
@@ -15,6 +16,26 @@ func AppPublicRoutes(userHandler handler.UserHandler, jobHandler handler.JobHand
 			Path:    "/login",
 			Handler: userHandler.Login,
 		},
+		{
+			Methode: http.MethodPost,
+			Path:    "/refresh",
+			Handler: userHandler.RefreshToken,
+		},
+		{
+			Methode: http.MethodPost,
+			Path:    "/password/forgot",
+			Handler: userHandler.ForgotPassword,
+		},
+		{
+			Methode: http.MethodPost,
+			Path:    "/password/reset",
+			Handler: userHandler.ResetPassword,
+		},
+		{
+			Methode: http.MethodPost,
+			Path:    "/email/verify",
+			Handler: userHandler.VerifyEmail,
+		},
 		{
 			Methode: http.MethodPost,
 			Path:    "/register",
@@ -25,6 +46,11 @@ func AppPublicRoutes(userHandler handler.UserHandler, jobHandler handler.JobHand
 			Path: "/jobs",
 			Handler: jobHandler.FindJobs,
 		},
+		{
+			Methode: http.MethodGet,
+			Path: "/jobs/search",
+			Handler: jobHandler.SearchJobs,
+		},
 		{
 			Methode: http.MethodGet,
 			Path: "/jobs/:id",
@@ -51,6 +77,16 @@ func AppPublicRoutes(userHandler handler.UserHandler, jobHandler handler.JobHand
 
 func AppPrivateRoute(userHandler handler.UserHandler,  jobHandler handler.JobHandler, jobApplicationHandler handler.JobApplicantsHandler, categoryHandeler handler.CategoryHandler) []*route.Route {
 	return []*route.Route{
+		{
+			Methode: http.MethodPost,
+			Path:    "/logout",
+			Handler: userHandler.Logout,
+		},
+		{
+			Methode: http.MethodPost,
+			Path:    "/email/verification",
+			Handler: userHandler.RequestEmailVerification,
+		},
 		{
 			Methode: http.MethodGet,
 			Path:    "/profile",
@@ -60,6 +96,7 @@ func AppPrivateRoute(userHandler handler.UserHandler,  jobHandler handler.JobHan
 			Methode: http.MethodGet,
 			Path:    "/users",
 			Handler: userHandler.FindAllUser,
+			Roles: []string{entity.RoleAdmin},
 		},
 		{
 			Methode: http.MethodPatch,
@@ -85,6 +122,7 @@ func AppPrivateRoute(userHandler handler.UserHandler,  jobHandler handler.JobHan
 			Methode: http.MethodPost,
 			Path: "/jobs",
 			Handler: jobHandler.CreateJob,
+			Roles: []string{entity.RoleAdmin, entity.RoleClient},
 		},
 		{
 			Methode: http.MethodPatch,
@@ -100,6 +138,7 @@ func AppPrivateRoute(userHandler handler.UserHandler,  jobHandler handler.JobHan
 			Methode: http.MethodPost,
 			Path: "/jobs/:jobID/apply",
 			Handler: jobApplicationHandler.ApplyJob,
+			Roles: []string{entity.RoleApplicant},
 		},
 		{
 			Methode: http.MethodGet,
@@ -115,21 +154,35 @@ func AppPrivateRoute(userHandler handler.UserHandler,  jobHandler handler.JobHan
 			Methode: http.MethodGet,
 			Path: "/jobs/:JobApplicantID/approve",
 			Handler: jobApplicationHandler.ApproveApplicant,
+			Roles: []string{entity.RoleAdmin, entity.RoleClient},
+		},
+		{
+			Methode: http.MethodPatch,
+			Path: "/applications/:jobApplicantID/status",
+			Handler: jobApplicationHandler.UpdateStatus,
+		},
+		{
+			Methode: http.MethodGet,
+			Path: "/applications/:jobApplicantID/history",
+			Handler: jobApplicationHandler.FindStatusHistory,
 		},
 		{
 			Methode: http.MethodPost,
 			Path: "/categories",
 			Handler: categoryHandeler.CreateCategory,
+			Roles: []string{entity.RoleAdmin},
 		},
 		{
 			Methode: http.MethodPatch,
 			Path: "/categories/:id",
 			Handler: categoryHandeler.UpdateCategory,
+			Roles: []string{entity.RoleAdmin},
 		},
 		{
 			Methode: http.MethodDelete,
 			Path: "/categories/:id",
 			Handler: categoryHandeler.DeleteCategory,
+			Roles: []string{entity.RoleAdmin},
 		},
 	}
 }
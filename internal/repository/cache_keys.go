@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	cacheExpire = 2 * time.Minute
+
+	// jobListTag covers every cached job listing, any job write invalidates it
+	jobListTag = "jobs"
+)
+
+func jobCacheKey(id uuid.UUID) string {
+	return fmt.Sprintf("job:%s", id)
+}
+
+func sharedJobsCacheKey(clientID uuid.UUID) string {
+	return fmt.Sprintf("jobs:shared:%s", clientID)
+}
+
+func jobTag(id uuid.UUID) string {
+	return fmt.Sprintf("job:%s", id)
+}
+
+func clientJobsTag(clientID uuid.UUID) string {
+	return fmt.Sprintf("jobs:client:%s", clientID)
+}
+
+// jobWriteTags are the tags to invalidate after a job owned by clientID changed.
+func jobWriteTags(id uuid.UUID, clientID uuid.UUID) []string {
+	return []string{jobListTag, jobTag(id), clientJobsTag(clientID)}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"github.com/DavidAfdal/workfinder/internal/entity"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -9,11 +10,11 @@ import (
 
 
 type CategoryRepository interface {
-	CreateCategory(category *entity.Category) (*entity.Category, error)
-	FindAllCategory() ([]entity.Category, error)
-	FindCategoryByID(id uuid.UUID) (*entity.Category, error)
-	UpdateCategory(category *entity.Category) (*entity.Category, error)
-	DeleteCategory(category *entity.Category) (bool, error)
+	CreateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error)
+	FindAllCategory(ctx context.Context) ([]entity.Category, error)
+	FindCategoryByID(ctx context.Context, id uuid.UUID) (*entity.Category, error)
+	UpdateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error)
+	DeleteCategory(ctx context.Context, category *entity.Category) (bool, error)
 }
 
 type categoryRepository struct {
@@ -26,32 +27,32 @@ func NewCategoryRepository(db *gorm.DB) CategoryRepository {
 }
 
 
-func (r *categoryRepository) CreateCategory(category *entity.Category) (*entity.Category, error) {
-	if err := r.db.Create(&category).Error; err != nil {
+func (r *categoryRepository) CreateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error) {
+	if err := r.db.WithContext(ctx).Create(&category).Error; err != nil {
 		return category, err
 	}
 	return category, nil
 }
 
-func (r *categoryRepository) FindAllCategory() ([]entity.Category, error) {
+func (r *categoryRepository) FindAllCategory(ctx context.Context) ([]entity.Category, error) {
 	categories := make([]entity.Category, 0)
 
-	if err := r.db.Find(&categories).Error; err != nil {
+	if err := r.db.WithContext(ctx).Find(&categories).Error; err != nil {
 		return categories, err
 	}
 	return categories, nil
 }
 
-func (r *categoryRepository) FindCategoryByID(id uuid.UUID) (*entity.Category, error) {
+func (r *categoryRepository) FindCategoryByID(ctx context.Context, id uuid.UUID) (*entity.Category, error) {
 	category := new(entity.Category)
 
-	if err := r.db.Where("id = ?", id).Preload("Jobs").First(&category).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Preload("Jobs").First(&category).Error; err != nil {
 		return category, err
 	}
 	return category, nil
 }
 
-func (r *categoryRepository) UpdateCategory(category *entity.Category) (*entity.Category, error) {
+func (r *categoryRepository) UpdateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error) {
 	fields := make(map[string]interface{})
 
 	if category.Title != "" {
@@ -61,14 +62,14 @@ func (r *categoryRepository) UpdateCategory(category *entity.Category) (*entity.
 		fields["icon"] = category.Icon
 	}
 
-	if err := r.db.Model(&category).Updates(fields).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&category).Updates(fields).Error; err != nil {
 		return category, err
 	}
 	return category, nil
 }
 
-func (r *categoryRepository) DeleteCategory(category *entity.Category) (bool, error) {
-	if err := r.db.Delete(&category).Error; err != nil {
+func (r *categoryRepository) DeleteCategory(ctx context.Context, category *entity.Category) (bool, error) {
+	if err := r.db.WithContext(ctx).Delete(&category).Error; err != nil {
 		return false, err
 	}
 	return true, nil
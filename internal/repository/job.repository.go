@@ -1,111 +1,129 @@
 package repository
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"time"
+	"strings"
 
 	"github.com/DavidAfdal/workfinder/internal/entity"
 	"github.com/DavidAfdal/workfinder/pkg/cache"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// headcountFilledReason is recorded on the applications rejected when a lower headcount closes the job.
+const headcountFilledReason = "the headcount of the job is filled"
 
 type JobRepository interface {
-	FindAllJob() ([]entity.Job, error)
-	FindJobByID(id uuid.UUID) (*entity.Job, error)
-	FindSharedJob(userId uuid.UUID) ([]entity.Job, error)
-	FindAppliedJob(userId uuid.UUID) ([]entity.Job, error)
-	CreateJob(job *entity.Job) (*entity.Job, error)
-	UpdateJob(job *entity.Job) (*entity.Job, error)
-	DeleteJob(job *entity.Job) (bool, error)
+	FindAllJob(ctx context.Context, filter *entity.JobFilter) (*entity.JobPage, error)
+	SearchJobs(ctx context.Context, search *entity.JobSearch) (*entity.JobSearchPage, error)
+	FindJobByID(ctx context.Context, id uuid.UUID) (*entity.Job, error)
+	FindSharedJob(ctx context.Context, userId uuid.UUID) ([]entity.Job, error)
+	FindAppliedJob(ctx context.Context, userId uuid.UUID) ([]entity.Job, error)
+	CreateJob(ctx context.Context, job *entity.Job) (*entity.Job, error)
+	UpdateJob(ctx context.Context, job *entity.Job, actorID uuid.UUID) (*entity.Job, error)
+	DeleteJob(ctx context.Context, job *entity.Job) (bool, error)
 }
 
 type jobRepository struct {
 	db *gorm.DB
 	cahce cache.Cacheable
+	loader cache.Loader
 }
 
 
-func NewJobRepository(db *gorm.DB, cahce cache.Cacheable) JobRepository {
-	return &jobRepository{db, cahce}
+func NewJobRepository(db *gorm.DB, cahce cache.Cacheable, loader cache.Loader) JobRepository {
+	return &jobRepository{db, cahce, loader}
 }
 
-func (r *jobRepository) FindAllJob() ([]entity.Job, error) {
-	// TODO: implement find all Jobs method
-	jobs := make([]entity.Job, 0)
-
-	key:= "GetAllJobs"
+func (r *jobRepository) FindAllJob(ctx context.Context, filter *entity.JobFilter) (*entity.JobPage, error) {
+	page := &entity.JobPage{Jobs: make([]entity.Job, 0), Limit: filter.Limit}
 
-	data := r.cahce.Get(key)
+	err := r.loader.Load(ctx, filter.CacheKey(), page, cacheExpire, func(ctx context.Context) (interface{}, error) {
+		page := &entity.JobPage{Limit: filter.Limit}
 
-	if data == "" {
-		if err := r.db.Preload("Category", func (db *gorm.DB) *gorm.DB {
-			return db.Select("title", "id", "icon")
-		}).Find(&jobs).Error; err != nil {
-			return jobs, err
+		if err := r.db.WithContext(ctx).Model(&entity.Job{}).Scopes(filterJobs(filter)).Count(&page.Total).Error; err != nil {
+			return nil, err
 		}
 
-		marshalJob, _:= json.Marshal(jobs)
-		err := r.cahce.Set(key, marshalJob, 2 * time.Minute)
+		jobs := make([]entity.Job, 0, filter.Limit+1)
 
-		if err != nil {
-			return jobs, err
+		if err := r.db.WithContext(ctx).Preload("Category", func (db *gorm.DB) *gorm.DB {
+			return db.Select("title", "id", "icon")
+		}).Scopes(filterJobs(filter), paginateJobs(filter)).Find(&jobs).Error; err != nil {
+			return nil, err
 		}
-	} else {
-		err := json.Unmarshal([]byte(data), &jobs)
-		if err != nil {
-			return jobs, err
+
+		if len(jobs) > filter.Limit {
+			jobs = jobs[:filter.Limit]
+			page.NextCursor = entity.EncodeJobCursor(entity.NewJobCursor(&jobs[len(jobs)-1]))
 		}
+		page.Jobs = jobs
+
+		return page, nil
+	}, jobListTag)
+
+	return page, err
+}
+
+// SearchJobs ranks jobs against the search_vector column, the query is only parsed once through the CROSS JOIN.
+func (r *jobRepository) SearchJobs(ctx context.Context, search *entity.JobSearch) (*entity.JobSearchPage, error) {
+	page := &entity.JobSearchPage{Results: make([]entity.JobSearchResult, 0), Page: search.Page, Limit: search.Limit}
+
+	headlineOptions := "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10"
+
+	query := r.db.WithContext(ctx).Model(&entity.Job{}).
+		Joins("CROSS JOIN to_tsquery('english', ?) AS query", search.TSQuery).
+		Where("search_vector @@ query")
+
+	if err := query.Count(&page.Total).Error; err != nil {
+		return page, err
 	}
 
+	if err := r.db.WithContext(ctx).Model(&entity.Job{}).
+		Select(`jobs.*,
+			ts_rank_cd(search_vector, query) AS rank,
+			ts_headline('english', title, query, ?) AS title_highlight,
+			ts_headline('english', company, query, ?) AS company_highlight,
+			ts_headline('english', coalesce(description, ''), query, ?) AS description_highlight`, headlineOptions, headlineOptions, headlineOptions).
+		Joins("CROSS JOIN to_tsquery('english', ?) AS query", search.TSQuery).
+		Where("search_vector @@ query").
+		Order("rank DESC, jobs.created_at DESC").
+		Limit(search.Limit).
+		Offset(search.Offset()).
+		Scan(&page.Results).Error; err != nil {
+		return page, err
+	}
 
-	return jobs, nil
+	return page, nil
 }
 
-func (r *jobRepository) FindSharedJob(userId uuid.UUID) ([]entity.Job, error) {
+func (r *jobRepository) FindSharedJob(ctx context.Context, userId uuid.UUID) ([]entity.Job, error) {
 	jobs := make([]entity.Job, 0)
 
-	key:= "GetSharedJobs"
-
-	data := r.cahce.Get(key)
+	err := r.loader.Load(ctx, sharedJobsCacheKey(userId), &jobs, cacheExpire, func(ctx context.Context) (interface{}, error) {
+		jobs := make([]entity.Job, 0)
 
-	if data == "" {
-		if err := r.db.Preload("Category", func (db *gorm.DB) *gorm.DB {
+		if err := r.db.WithContext(ctx).Preload("Category", func (db *gorm.DB) *gorm.DB {
 			return db.Select("title", "id", "icon")
 		}).Find(&jobs, "client_id = ?", userId).Error; err != nil {
-			return jobs, err
+			return nil, err
 		}
 
-		marshalJob, _:= json.Marshal(jobs)
-		err := r.cahce.Set(key, marshalJob, 2 * time.Minute)
-
-		if err != nil {
-			return jobs, err
-		}
-	} else {
-		err := json.Unmarshal([]byte(data), &jobs)
-		if err != nil {
-			return jobs, err
-		}
-	}
-
-
-	return jobs, nil
+		return jobs, nil
+	}, clientJobsTag(userId))
 
+	return jobs, err
 }
 
-func (r *jobRepository) FindJobByID(id uuid.UUID) (*entity.Job, error) {
+func (r *jobRepository) FindJobByID(ctx context.Context, id uuid.UUID) (*entity.Job, error) {
 	job := new(entity.Job)
 
+	err := r.loader.Load(ctx, jobCacheKey(id), job, cacheExpire, func(ctx context.Context) (interface{}, error) {
+		job := new(entity.Job)
 
-	key := fmt.Sprintf("job_%s", id)
-
-	data := r.cahce.Get(key)
-
-	if data == "" {
-		if err := r.db.Preload("Applicants", func(db *gorm.DB) *gorm.DB {
+		if err := r.db.WithContext(ctx).Preload("Applicants", func(db *gorm.DB) *gorm.DB {
 			return db.Preload("Applicant", func(db *gorm.DB) *gorm.DB {
 				return db.Select("name", "id")
 			})
@@ -119,35 +137,25 @@ func (r *jobRepository) FindJobByID(id uuid.UUID) (*entity.Job, error) {
 		Where("id = ?", id).
 		First(&job).Error;
 		err != nil {
-			return job, err
+			return nil, err
 		}
 
 		if len(job.Applicants) == 0 {
 			job.Applicants = make([]*entity.JobApplicants, 0)
 		}
 
-		marshalJob, _:= json.Marshal(job)
-		err := r.cahce.Set(key, marshalJob, 2 * time.Minute)
+		return job, nil
+	}, jobTag(id))
 
-		if err != nil {
-			return job, err
-		}
-	} else {
-		err := json.Unmarshal([]byte(data), &job)
-		if err != nil {
-			return job, err
-		}
-	}
-
-	return job, nil
+	return job, err
 }
 
-func (r *jobRepository) FindAppliedJob(userId uuid.UUID) ([]entity.Job, error){
+func (r *jobRepository) FindAppliedJob(ctx context.Context, userId uuid.UUID) ([]entity.Job, error){
 
 	jobs := make([]entity.Job, 0)
 	applicant_jobs := make([]entity.JobApplicants, 0)
 
-	if err := r.db.Where("applicant_id = ?", userId).Preload("Job.Category").Find(&applicant_jobs).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("applicant_id = ?", userId).Preload("Job.Category").Find(&applicant_jobs).Error; err != nil {
 		return jobs, err
 	}
 
@@ -160,15 +168,15 @@ func (r *jobRepository) FindAppliedJob(userId uuid.UUID) ([]entity.Job, error){
 }
 
 
-func (r *jobRepository) CreateJob(job *entity.Job) (*entity.Job, error) {
-	if err := r.db.Create(&job).Error; err != nil {
+func (r *jobRepository) CreateJob(ctx context.Context, job *entity.Job) (*entity.Job, error) {
+	if err := r.db.WithContext(ctx).Create(&job).Error; err != nil {
 		return job, err
 	}
 
-	return job, nil
+	return job, r.cahce.Invalidate(ctx, jobListTag, clientJobsTag(job.ClientID))
 }
 
-func (r *jobRepository) UpdateJob(job *entity.Job) (*entity.Job, error) {
+func (r *jobRepository) UpdateJob(ctx context.Context, job *entity.Job, actorID uuid.UUID) (*entity.Job, error) {
 	fields := make(map[string]interface{})
 
 	if job.Title != "" {
@@ -199,17 +207,126 @@ func (r *jobRepository) UpdateJob(job *entity.Job) (*entity.Job, error) {
 		fields["salary"] = job.Salary
 	}
 
-	if err := r.db.Model(&job).Updates(fields).Error; err != nil {
+	if job.Headcount <= 0 {
+		if err := r.db.WithContext(ctx).Model(&job).Updates(fields).Error; err != nil {
+			return job, err
+		}
+
+		return job, r.cahce.Invalidate(ctx, jobWriteTags(job.ID, job.ClientID)...)
+	}
+
+	fields["headcount"] = job.Headcount
+
+	// the job is closed once the headcount is filled, rejecting the open applications like ApproveApplicant does,
+	// and reopened when the headcount is raised above the hired applicants. The job row is locked so a concurrent
+	// approval sees the new headcount.
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current := new(entity.Job)
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", job.ID).First(&current).Error; err != nil {
+			return err
+		}
+
+		var hired int64
+
+		if err := tx.Model(&entity.JobApplicants{}).Where("job_id = ? AND status = ?", job.ID, entity.ApplicationHired).Count(&hired).Error; err != nil {
+			return err
+		}
+
+		job.Closed = hired >= int64(job.Headcount)
+		fields["closed"] = job.Closed
+
+		if err := tx.Model(&job).Updates(fields).Error; err != nil {
+			return err
+		}
+
+		if !job.Closed || current.Closed {
+			return nil
+		}
+
+		return rejectOpenApplications(tx, job.ID, actorID, headcountFilledReason)
+	})
+
+	if err != nil {
 		return job, err
 	}
 
-	return job, nil
+	return job, r.cahce.Invalidate(ctx, jobWriteTags(job.ID, job.ClientID)...)
 }
 
 
-func (r *jobRepository) DeleteJob(job *entity.Job) (bool, error){
-	if err:= r.db.Delete(&job).Error; err != nil {
+func (r *jobRepository) DeleteJob(ctx context.Context, job *entity.Job) (bool, error){
+	if err:= r.db.WithContext(ctx).Delete(&job).Error; err != nil {
 		return false, nil
 	}
+
+	if err := r.cahce.Invalidate(ctx, jobWriteTags(job.ID, job.ClientID)...); err != nil {
+		return true, err
+	}
 	return true, nil
 }
+
+func filterJobs(filter *entity.JobFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.CategoryID != nil {
+			db = db.Where("category_id = ?", *filter.CategoryID)
+		}
+
+		if filter.Location != "" {
+			db = db.Where("LOWER(location) = ?", filter.Location)
+		}
+
+		if filter.Status != "" {
+			db = db.Where("LOWER(status) = ?", filter.Status)
+		}
+
+		if filter.MinSalary > 0 {
+			db = db.Where("salary >= ?", filter.MinSalary)
+		}
+
+		if filter.MaxSalary > 0 {
+			db = db.Where("salary <= ?", filter.MaxSalary)
+		}
+
+		if filter.Closed != nil {
+			db = db.Where("closed = ?", *filter.Closed)
+		}
+
+		if filter.Keyword != "" {
+			keyword := "%" + escapeLike(filter.Keyword) + "%"
+			db = db.Where("(title ILIKE ? OR company ILIKE ? OR description ILIKE ?)", keyword, keyword, keyword)
+		}
+
+		return db
+	}
+}
+
+// paginateJobs orders jobs by the requested sort and uses the cursor as a keyset, id breaks ties between equal sort values.
+func paginateJobs(filter *entity.JobFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		column, direction, operator := "created_at", "DESC", "<"
+
+		switch filter.Sort {
+		case entity.JobSortOldest:
+			direction, operator = "ASC", ">"
+		case entity.JobSortSalaryDesc:
+			column = "salary"
+		case entity.JobSortSalaryAsc:
+			column, direction, operator = "salary", "ASC", ">"
+		}
+
+		if filter.Cursor != nil {
+			var value interface{} = filter.Cursor.CreatedAt
+			if column == "salary" {
+				value = filter.Cursor.Salary
+			}
+			db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, operator), value, filter.Cursor.ID)
+		}
+
+		return db.Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).Limit(filter.Limit + 1)
+	}
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/pkg/cache"
+	"github.com/google/uuid"
+)
+
+func newTestJobRepository(t *testing.T) (JobRepository, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock := newTestDB(t)
+	cacheable := newTestCache(t)
+
+	return NewJobRepository(db, cacheable, cache.NewLoader(cacheable, 0, 0)), mock
+}
+
+func jobRows(jobs ...*entity.Job) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "title", "client_id", "category_id", "created_at"})
+	for _, job := range jobs {
+		rows.AddRow(job.ID, job.Title, job.ClientID, job.CategoryID, time.Now())
+	}
+	return rows
+}
+
+func expectFindJobByID(mock sqlmock.Sqlmock, job *entity.Job) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "jobs" WHERE id = $1`)).WillReturnRows(jobRows(job))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "job_applicants"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "title","id","icon" FROM "categories"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","name","email" FROM "users"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+}
+
+func TestFindSharedJobIsCachedPerClient(t *testing.T) {
+	r, mock := newTestJobRepository(t)
+
+	categoryID := uuid.New()
+	jobOfA := &entity.Job{ID: uuid.New(), Title: "Job of client A", ClientID: uuid.New(), CategoryID: categoryID}
+	jobOfB := &entity.Job{ID: uuid.New(), Title: "Job of client B", ClientID: uuid.New(), CategoryID: categoryID}
+
+	for _, job := range []*entity.Job{jobOfA, jobOfB} {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "jobs" WHERE client_id = $1`)).
+			WithArgs(job.ClientID).
+			WillReturnRows(jobRows(job))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "title","id","icon" FROM "categories"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		jobs, err := r.FindSharedJob(ctx, job.ClientID)
+		if err != nil {
+			t.Fatalf("FindSharedJob() error = %v", err)
+		}
+		if len(jobs) != 1 || jobs[0].ID != job.ID {
+			t.Fatalf("FindSharedJob(%s) = %+v, want only %q", job.ClientID, jobs, job.Title)
+		}
+	}
+
+	// both lists are cached now, each client still only sees its own jobs
+	for _, job := range []*entity.Job{jobOfA, jobOfB} {
+		jobs, err := r.FindSharedJob(ctx, job.ClientID)
+		if err != nil {
+			t.Fatalf("FindSharedJob() error = %v", err)
+		}
+		if len(jobs) != 1 || jobs[0].ID != job.ID {
+			t.Fatalf("cached FindSharedJob(%s) = %+v, want only %q", job.ClientID, jobs, job.Title)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJobWritesEvictCachedJob(t *testing.T) {
+	r, mock := newTestJobRepository(t)
+
+	job := &entity.Job{ID: uuid.New(), Title: "Backend Engineer", ClientID: uuid.New(), CategoryID: uuid.New()}
+
+	expectFindJobByID(mock, job)
+
+	for i := 0; i < 2; i++ {
+		found, err := r.FindJobByID(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("FindJobByID() error = %v", err)
+		}
+		if found.Title != "Backend Engineer" {
+			t.Fatalf("FindJobByID() title = %q", found.Title)
+		}
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "jobs" SET`)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := r.UpdateJob(ctx, &entity.Job{ID: job.ID, Title: "Senior Backend Engineer", ClientID: job.ClientID}, job.ClientID); err != nil {
+		t.Fatalf("UpdateJob() error = %v", err)
+	}
+
+	updated := *job
+	updated.Title = "Senior Backend Engineer"
+	expectFindJobByID(mock, &updated)
+
+	found, err := r.FindJobByID(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("FindJobByID() error = %v", err)
+	}
+	if found.Title != "Senior Backend Engineer" {
+		t.Fatalf("FindJobByID() after update title = %q, want the updated job", found.Title)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "jobs" SET "deleted_at"`)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := r.DeleteJob(ctx, &updated); err != nil {
+		t.Fatalf("DeleteJob() error = %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "jobs" WHERE id = $1`)).WillReturnRows(jobRows())
+
+	if _, err := r.FindJobByID(ctx, job.ID); err == nil {
+		t.Fatal("FindJobByID() after delete returned the cached job")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateJobHeadcountClosesAndReopensJob(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		wasClosed   bool
+		headcount   int
+		wantClosed  bool
+		wantRejects bool
+	}{
+		{"filled by the hired applicants", false, 2, true, true},
+		{"lowered on a closed job", true, 1, true, false},
+		{"raised above the hired applicants", true, 3, false, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock := newTestJobRepository(t)
+			job := &entity.Job{ID: uuid.New(), ClientID: uuid.New(), Headcount: tt.headcount}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "jobs" WHERE id = $1 AND "jobs"."deleted_at" IS NULL ORDER BY "jobs"."id" LIMIT $2 FOR UPDATE`)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "client_id", "closed"}).AddRow(job.ID, job.ClientID, tt.wasClosed))
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "job_applicants" WHERE (job_id = $1 AND status = $2)`)).
+				WithArgs(job.ID, entity.ApplicationHired).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+			mock.ExpectExec(regexp.QuoteMeta(`UPDATE "jobs" SET "closed"=$1,"headcount"=$2`)).
+				WithArgs(tt.wantClosed, tt.headcount, sqlmock.AnyArg(), job.ID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			if tt.wantRejects {
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO application_status_history`)).
+					WithArgs(entity.ApplicationRejected, job.ClientID, headcountFilledReason, job.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "job_applicants" SET "status"=$1,"updated_at"=$2 WHERE (job_id = $3 AND status NOT IN ($4,$5,$6))`)).
+					WithArgs(entity.ApplicationRejected, sqlmock.AnyArg(), job.ID, entity.ApplicationHired, entity.ApplicationRejected, entity.ApplicationWithdrawn).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			}
+			mock.ExpectCommit()
+
+			updated, err := r.UpdateJob(ctx, job, job.ClientID)
+			if err != nil {
+				t.Fatalf("UpdateJob() error = %v", err)
+			}
+			if updated.Closed != tt.wantClosed {
+				t.Fatalf("UpdateJob() closed = %t, want %t", updated.Closed, tt.wantClosed)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
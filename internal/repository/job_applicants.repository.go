@@ -1,39 +1,94 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+	"github.com/DavidAfdal/workfinder/pkg/cache"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 
+const jobApplicantsUniqueIndex = "uq_job_applicants_job_id_applicant_id"
+
+var (
+	ErrApplicationStatusChanged = apperror.Conflict("application_status_changed", "application status was changed by another request")
+	ErrJobClosed                = apperror.Conflict("job_closed", "job already closed")
+	ErrAlreadyApplied           = apperror.Conflict("already_applied", "you have already applied for this job")
+)
+
 type JobApplicantsRepository interface {
-	ApplyJob(jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error)
-	FindJobApplicantsByID(id uuid.UUID) (*entity.JobApplicants, error)
-	WithdrawJob(jobApplicant *entity.JobApplicants) (bool, error)
-	ApproveApplicant(jobApplicants *entity.JobApplicants) (*entity.JobApplicants, error)
+	ApplyJob(ctx context.Context, jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error)
+	ReapplyJob(ctx context.Context, withdrawn *entity.JobApplicants, jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error)
+	FindJobApplicantsByID(ctx context.Context, id uuid.UUID) (*entity.JobApplicants, error)
+	FindApplication(ctx context.Context, jobID uuid.UUID, applicantID uuid.UUID) (*entity.JobApplicants, error)
+	UpdateStatus(ctx context.Context, jobApplicant *entity.JobApplicants, toStatus string, actorID uuid.UUID, reason string) (*entity.JobApplicants, error)
+	FindStatusHistory(ctx context.Context, jobApplicantID uuid.UUID) ([]entity.ApplicationStatusHistory, error)
+	ApproveApplicant(ctx context.Context, jobApplicants *entity.JobApplicants, actorID uuid.UUID, rejectionMessage string) (*entity.JobApplicants, error)
 }
 
 type jobApplicantsRepository struct {
 	db *gorm.DB
+	cahce cache.Cacheable
 }
 
 
-func NewJobApplicantsRepository(db *gorm.DB) JobApplicantsRepository {
-	return &jobApplicantsRepository{db}
+func NewJobApplicantsRepository(db *gorm.DB, cahce cache.Cacheable) JobApplicantsRepository {
+	return &jobApplicantsRepository{db, cahce}
 }
 
-func (r *jobApplicantsRepository) ApplyJob(jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error) {
-	if err := r.db.Create(&jobApplicant).Error; err != nil {
+func (r *jobApplicantsRepository) ApplyJob(ctx context.Context, jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createApplication(tx, jobApplicant)
+	})
+
+	if err != nil {
+		return jobApplicant, err
+	}
+
+	return jobApplicant, r.cahce.Invalidate(ctx, jobTag(jobApplicant.JobID))
+}
+
+// ReapplyJob soft deletes a withdrawn application and creates a fresh one, the old row keeps its history.
+func (r *jobApplicantsRepository) ReapplyJob(ctx context.Context, withdrawn *entity.JobApplicants, jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("status = ?", entity.ApplicationWithdrawn).Delete(&entity.JobApplicants{}, "id = ?", withdrawn.ID)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrApplicationStatusChanged
+		}
+
+		return createApplication(tx, jobApplicant)
+	})
+
+	if err != nil {
+		return jobApplicant, err
+	}
+
+	return jobApplicant, r.cahce.Invalidate(ctx, jobTag(jobApplicant.JobID))
+}
+
+func (r *jobApplicantsRepository) FindApplication(ctx context.Context, jobID uuid.UUID, applicantID uuid.UUID) (*entity.JobApplicants, error) {
+	jobApplicant := new(entity.JobApplicants)
+
+	if err := r.db.WithContext(ctx).Where("job_id = ? AND applicant_id = ?", jobID, applicantID).First(&jobApplicant).Error; err != nil {
 		return jobApplicant, err
 	}
 
 	return jobApplicant, nil
 }
 
-func (r *jobApplicantsRepository) FindJobApplicantsByID(id uuid.UUID) (*entity.JobApplicants, error)  {
+func (r *jobApplicantsRepository) FindJobApplicantsByID(ctx context.Context, id uuid.UUID) (*entity.JobApplicants, error)  {
 	jobApplicant := new(entity.JobApplicants)
-	if err := r.db.Preload("Applicant", func(db *gorm.DB) *gorm.DB{
+	if err := r.db.WithContext(ctx).Preload("Applicant", func(db *gorm.DB) *gorm.DB{
 		return db.Select("name", "id", "email")
 	}).First(&jobApplicant, "id = ?", id).Error; err != nil {
 		return jobApplicant, err
@@ -42,34 +97,122 @@ func (r *jobApplicantsRepository) FindJobApplicantsByID(id uuid.UUID) (*entity.J
 	return jobApplicant, nil
 }
 
-func (r *jobApplicantsRepository) WithdrawJob(jobApplicant *entity.JobApplicants) (bool, error)  {
-	if err := r.db.Delete(&jobApplicant).Error; err != nil {
-		return false, err
+// UpdateStatus only moves the application when it still has the status the caller validated the transition from.
+func (r *jobApplicantsRepository) UpdateStatus(ctx context.Context, jobApplicant *entity.JobApplicants, toStatus string, actorID uuid.UUID, reason string) (*entity.JobApplicants, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return updateApplicationStatus(tx, jobApplicant, toStatus, actorID, reason)
+	})
+
+	if err != nil {
+		return jobApplicant, err
+	}
+
+	return jobApplicant, r.cahce.Invalidate(ctx, jobTag(jobApplicant.JobID))
+}
+
+func (r *jobApplicantsRepository) FindStatusHistory(ctx context.Context, jobApplicantID uuid.UUID) ([]entity.ApplicationStatusHistory, error) {
+	history := make([]entity.ApplicationStatusHistory, 0)
+
+	if err := r.db.WithContext(ctx).Where("job_applicant_id = ?", jobApplicantID).Order("created_at ASC").Find(&history).Error; err != nil {
+		return history, err
 	}
-	return true, nil
+
+	return history, nil
 }
 
-func (r *jobApplicantsRepository) ApproveApplicant(jobApplicants *entity.JobApplicants) (*entity.JobApplicants, error) {
+// ApproveApplicant hires the applicant while holding a lock on the job row, so concurrent approvals can't
+// hire more people than the headcount. Once the headcount is filled the job is closed and every other open
+// application of that job is rejected with rejectionMessage.
+func (r *jobApplicantsRepository) ApproveApplicant(ctx context.Context, jobApplicants *entity.JobApplicants, actorID uuid.UUID, rejectionMessage string) (*entity.JobApplicants, error) {
+	job := new(entity.Job)
 
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		if err := r.db.Model(&jobApplicants).Update("status", "Approved").Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", jobApplicants.JobID).First(&job).Error; err != nil {
 			return err
 		}
 
-		if err := r.db.Model(&entity.JobApplicants{}).Where("id != ?", jobApplicants.ID).Update("status", "Rejected").Error; err != nil {
+		if job.Closed {
+			return ErrJobClosed
+		}
+
+		if err := updateApplicationStatus(tx, jobApplicants, entity.ApplicationHired, actorID, ""); err != nil {
 			return err
 		}
 
-		if err := r.db.Model(&entity.Job{}).Where("id = ?", jobApplicants.Job.ID).Update("closed", true).Error; err != nil {
+		var hired int64
+
+		if err := tx.Model(&entity.JobApplicants{}).Where("job_id = ? AND status = ?", job.ID, entity.ApplicationHired).Count(&hired).Error; err != nil {
 			return err
 		}
 
-		return nil
+		if hired < int64(job.Headcount) {
+			return nil
+		}
+
+		if err := tx.Model(&entity.Job{}).Where("id = ?", job.ID).Update("closed", true).Error; err != nil {
+			return err
+		}
+
+		return rejectOpenApplications(tx, job.ID, actorID, rejectionMessage)
 	})
 
 	if err != nil {
 		return jobApplicants, err
 	}
 
-	return jobApplicants, nil
+	// hiring may close the job, which changes the listings as well as the job itself
+	return jobApplicants, r.cahce.Invalidate(ctx, jobWriteTags(job.ID, job.ClientID)...)
+}
+
+// rejectOpenApplications rejects every application of the job that isn't final yet and records it in the history.
+func rejectOpenApplications(tx *gorm.DB, jobID uuid.UUID, actorID uuid.UUID, rejectionMessage string) error {
+	finalStatuses := entity.FinalApplicationStatuses()
+
+	if err := tx.Exec(`INSERT INTO application_status_history (id, job_applicant_id, from_status, to_status, actor_id, reason, created_at)
+		SELECT gen_random_uuid(), id, status, ?, ?, ?, now() FROM job_applicants
+		WHERE job_id = ? AND status NOT IN ? AND deleted_at IS NULL`,
+		entity.ApplicationRejected, actorID, rejectionMessage, jobID, finalStatuses).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&entity.JobApplicants{}).
+		Where("job_id = ? AND status NOT IN ?", jobID, finalStatuses).
+		Updates(map[string]interface{}{"status": entity.ApplicationRejected, "updated_at": time.Now()}).Error
+}
+
+func updateApplicationStatus(tx *gorm.DB, jobApplicant *entity.JobApplicants, toStatus string, actorID uuid.UUID, reason string) error {
+	fromStatus := jobApplicant.Status
+
+	result := tx.Model(&entity.JobApplicants{}).
+		Where("id = ? AND status = ?", jobApplicant.ID, fromStatus).
+		Updates(map[string]interface{}{"status": toStatus, "updated_at": time.Now()})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrApplicationStatusChanged
+	}
+
+	if err := tx.Create(entity.NewApplicationStatusHistory(jobApplicant.ID, fromStatus, toStatus, actorID, reason)).Error; err != nil {
+		return err
+	}
+
+	jobApplicant.Status = toStatus
+
+	return nil
+}
+
+// createApplication inserts the application with its first history entry. The partial unique index on
+// (job_id, applicant_id) is the real duplicate check, its violation is reported as ErrAlreadyApplied.
+func createApplication(tx *gorm.DB, jobApplicant *entity.JobApplicants) error {
+	if err := tx.Create(&jobApplicant).Error; err != nil {
+		if isUniqueViolation(err, jobApplicantsUniqueIndex) {
+			return ErrAlreadyApplied
+		}
+		return err
+	}
+
+	return tx.Create(entity.NewApplicationStatusHistory(jobApplicant.ID, "", jobApplicant.Status, jobApplicant.ApplicantID, "")).Error
 }
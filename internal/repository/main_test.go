@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/DavidAfdal/workfinder/pkg/cache"
+	"github.com/DavidAfdal/workfinder/pkg/encrypt"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testEncryptTool encrypt.BindingTool
+
+// ctx is what the tests pass to repositories.
+var ctx = context.Background()
+
+// TestMain registers the encrypted serializer, entity.User can't be parsed by GORM without it.
+func TestMain(m *testing.M) {
+	var err error
+
+	testEncryptTool, err = encrypt.NewGCMTool("1", "0123456789abcdef0123456789abcdef")
+	if err != nil {
+		panic(err)
+	}
+	encrypt.RegisterSerializer(testEncryptTool)
+
+	os.Exit(m.Run())
+}
+
+func newTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	return db, mock
+}
+
+func newTestCache(t *testing.T) cache.Cacheable {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return cache.NewCacheable(client, "workfinder", "test")
+}
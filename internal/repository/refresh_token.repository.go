@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrRefreshTokenAlreadyRotated = apperror.Conflict("refresh_token_already_rotated", "refresh token already rotated")
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, refreshToken *entity.RefreshToken) (*entity.RefreshToken, error)
+	FindRefreshTokenByHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldToken *entity.RefreshToken, newToken *entity.RefreshToken) (*entity.RefreshToken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db}
+}
+
+func (r *refreshTokenRepository) CreateRefreshToken(ctx context.Context, refreshToken *entity.RefreshToken) (*entity.RefreshToken, error) {
+	if err := r.db.WithContext(ctx).Create(&refreshToken).Error; err != nil {
+		return refreshToken, err
+	}
+
+	return refreshToken, nil
+}
+
+func (r *refreshTokenRepository) FindRefreshTokenByHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	refreshToken := new(entity.RefreshToken)
+
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&refreshToken).Error; err != nil {
+		return refreshToken, err
+	}
+
+	return refreshToken, nil
+}
+
+// RotateRefreshToken revokes oldToken and stores newToken in one transaction. The revoke is conditional so
+// two requests racing with the same token can't both rotate it.
+func (r *refreshTokenRepository) RotateRefreshToken(ctx context.Context, oldToken *entity.RefreshToken, newToken *entity.RefreshToken) (*entity.RefreshToken, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newToken).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&entity.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", oldToken.ID).
+			Updates(map[string]interface{}{
+				"revoked_at":     time.Now(),
+				"replaced_by_id": newToken.ID,
+			})
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenAlreadyRotated
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return newToken, err
+	}
+
+	return newToken, nil
+}
+
+func (r *refreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&entity.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
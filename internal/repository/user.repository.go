@@ -1,91 +1,88 @@
 package repository
 
 import (
-	"encoding/json"
-	"fmt"
-	"time"
+	"context"
 
 	"github.com/DavidAfdal/workfinder/internal/entity"
-	"github.com/DavidAfdal/workfinder/pkg/cache"
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+	"github.com/DavidAfdal/workfinder/pkg/encrypt"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+const usersEmailKey = "users_email_key"
+
+var ErrEmailAlreadyRegistered = apperror.Conflict("email_already_registered", "email already registered")
 
 type UserRepository interface {
-	FindAllUser() ([]entity.User, error)
-	FindByEmail(email string) (*entity.User, error)
-	FindById(id uuid.UUID) (*entity.User, error)
-	CreateUser(user *entity.User) (*entity.User, error)
-	UpdateUser(user *entity.User) (*entity.User, error)
-	DeleteUser(user *entity.User) (bool, error)
+	FindAllUser(ctx context.Context) ([]entity.User, error)
+	FindByEmail(ctx context.Context, email string) (*entity.User, error)
+	FindByPhoneNumber(ctx context.Context, phoneNumber string) (*entity.User, error)
+	FindById(ctx context.Context, id uuid.UUID) (*entity.User, error)
+	CreateUser(ctx context.Context, user *entity.User) (*entity.User, error)
+	UpdateUser(ctx context.Context, user *entity.User) (*entity.User, error)
+	DeleteUser(ctx context.Context, user *entity.User) (bool, error)
 }
 
 type userRepository struct {
 	db *gorm.DB
-	cahce cache.Cacheable
+	blindIndex encrypt.BlindIndex
 }
 
-func NewUserRepository(db *gorm.DB, cahce cache.Cacheable) UserRepository {
-	return &userRepository{db, cahce}
+func NewUserRepository(db *gorm.DB, blindIndex encrypt.BlindIndex) UserRepository {
+	return &userRepository{db, blindIndex}
 }
 
-
-func (r *userRepository) FindAllUser() ([]entity.User, error) {
+// FindAllUser always reads the database, users aren't cached since the cache would hold their decrypted
+// address and phone number.
+func (r *userRepository) FindAllUser(ctx context.Context) ([]entity.User, error) {
 	users := make([]entity.User, 0)
 
+	if err := r.db.WithContext(ctx).Find(&users).Error; err != nil {
+		return users, err
+	}
 
-	key:= "GetAllUsers"
-
-	data := r.cahce.Get(key)
-
-	fmt.Println(data)
-
-	if data == "" {
-		if err := r.db.Find(&users).Error; err != nil {
-			return users, err
-		}
+	return users, nil
+}
 
-		marshalJob, _:= json.Marshal(users)
-		err := r.cahce.Set(key, marshalJob, 2 * time.Minute)
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
+	user := new(entity.User)
 
-		if err != nil {
-			return users, err
-		}
-	} else {
-		err := json.Unmarshal([]byte(data), &users)
-		if err != nil {
-			return users, err
-		}
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return user, err
 	}
 
-
-
-	return users, nil
+	return user, nil
 }
 
-func (r *userRepository) FindByEmail(email string) (*entity.User, error) {
+// FindByPhoneNumber compares blind indexes, phone_number itself is encrypted with a random nonce.
+func (r *userRepository) FindByPhoneNumber(ctx context.Context, phoneNumber string) (*entity.User, error) {
 	user := new(entity.User)
 
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("phone_number_index = ?", r.blindIndex.Index(phoneNumber)).First(&user).Error; err != nil {
 		return user, err
 	}
 
 	return user, nil
 }
 
-func (r *userRepository) FindById(id uuid.UUID) (*entity.User, error) {
+func (r *userRepository) FindById(ctx context.Context, id uuid.UUID) (*entity.User, error) {
 	user := new(entity.User)
 
-	if err := r.db.Where("id = ?", id).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error; err != nil {
 		return user, err
 	}
 
 	return user, nil
 }
 
-func (r *userRepository) CreateUser(user *entity.User) (*entity.User, error) {
-	if err := r.db.Create(&user).Error; err != nil {
+func (r *userRepository) CreateUser(ctx context.Context, user *entity.User) (*entity.User, error) {
+	user.PhoneNumberIndex = r.blindIndex.Index(user.PhoneNumber)
+
+	if err := r.db.WithContext(ctx).Create(&user).Error; err != nil {
+		if isUniqueViolation(err, usersEmailKey) {
+			return user, ErrEmailAlreadyRegistered
+		}
 		return user, err
 	}
 
@@ -93,30 +90,37 @@ func (r *userRepository) CreateUser(user *entity.User) (*entity.User, error) {
 }
 
 
-func (r *userRepository) UpdateUser(user *entity.User) (*entity.User, error) {
-	fields := make(map[string]interface{})
+// UpdateUser only writes the non-empty fields. It updates from the struct rather than a map, since GORM only
+// runs the encrypted serializer for struct values.
+func (r *userRepository) UpdateUser(ctx context.Context, user *entity.User) (*entity.User, error) {
+	columns := make([]string, 0, 4)
 
 	if user.Password != "" {
-		fields["password"] = user.Password
+		columns = append(columns, "password")
 	}
 
 	if user.Address != "" {
-		fields["address" ] = user.Address
+		columns = append(columns, "address")
 	}
 
 	if user.PhoneNumber != "" {
-		fields["phone_number" ] = user.PhoneNumber
+		user.PhoneNumberIndex = r.blindIndex.Index(user.PhoneNumber)
+		columns = append(columns, "phone_number", "phone_number_index")
+	}
+
+	if len(columns) == 0 {
+		return user, nil
 	}
 
-	if err := r.db.Model(&user).Updates(fields).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(user).Select(columns).Updates(user).Error; err != nil {
 		return user, err
 	}
 
 	return user, nil
 }
 
-func (r *userRepository) DeleteUser(user *entity.User) (bool, error){
-	if err:= r.db.Delete(&user).Error; err != nil {
+func (r *userRepository) DeleteUser(ctx context.Context, user *entity.User) (bool, error){
+	if err:= r.db.WithContext(ctx).Delete(&user).Error; err != nil {
 		return false, nil
 	}
 
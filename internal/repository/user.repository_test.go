@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/pkg/encrypt"
+	"github.com/google/uuid"
+)
+
+// encryptedArg matches a ciphertext of plaintext bound to the column of the user with id, nonces are random so
+// the exact value can't be expected. id is a pointer since BeforeCreate only sets it once the insert runs.
+type encryptedArg struct {
+	column    string
+	id        *uuid.UUID
+	plaintext string
+}
+
+func (a encryptedArg) Match(value driver.Value) bool {
+	text, ok := value.(string)
+	if !ok || !encrypt.IsBound(text) {
+		return false
+	}
+
+	plaintext, err := testEncryptTool.DecryptBound(text, encrypt.Binding("users", a.column, *a.id))
+
+	return err == nil && plaintext == a.plaintext
+}
+
+func newTestUserRepository(t *testing.T) (UserRepository, sqlmock.Sqlmock, encrypt.BlindIndex) {
+	t.Helper()
+
+	db, mock := newTestDB(t)
+
+	blindIndex, err := encrypt.NewBlindIndex("test-blind-index-key")
+	if err != nil {
+		t.Fatalf("NewBlindIndex() error = %v", err)
+	}
+
+	return NewUserRepository(db, blindIndex), mock, blindIndex
+}
+
+func TestCreateUserEncryptsPII(t *testing.T) {
+	r, mock, blindIndex := newTestUserRepository(t)
+
+	user := entity.NewUser("Budi", "budi@example.com", "hashed", "Jl. Merdeka 1", "+6281234567890", entity.GenderMale, entity.RoleApplicant)
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "users"`)).
+		WithArgs(sqlmock.AnyArg(), "Budi", "budi@example.com", "hashed", encryptedArg{"address", &user.ID, "Jl. Merdeka 1"}, encryptedArg{"phone_number", &user.ID, "+6281234567890"},
+			blindIndex.Index("+6281234567890"), entity.GenderMale, entity.RoleApplicant, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := r.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindByPhoneNumberUsesBlindIndex(t *testing.T) {
+	r, mock, blindIndex := newTestUserRepository(t)
+
+	id := uuid.New()
+	address, _ := testEncryptTool.EncryptBound("Jl. Merdeka 1", encrypt.Binding("users", "address", id))
+	phoneNumber, _ := testEncryptTool.EncryptBound("+6281234567890", encrypt.Binding("users", "phone_number", id))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE phone_number_index = $1`)).
+		WithArgs(blindIndex.Index("+6281234567890"), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "address", "phone_number"}).AddRow(id, "Budi", address, phoneNumber))
+
+	user, err := r.FindByPhoneNumber(ctx, "+6281234567890")
+	if err != nil {
+		t.Fatalf("FindByPhoneNumber() error = %v", err)
+	}
+
+	if user.Address != "Jl. Merdeka 1" || user.PhoneNumber != "+6281234567890" {
+		t.Fatalf("FindByPhoneNumber() = %q, %q, want decrypted values", user.Address, user.PhoneNumber)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindUserRejectsValuesOfAnotherRow(t *testing.T) {
+	r, mock, _ := newTestUserRepository(t)
+
+	// the address of another user copied into this row
+	address, _ := testEncryptTool.EncryptBound("Jl. Merdeka 1", encrypt.Binding("users", "address", uuid.New()))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "address"}).AddRow(uuid.New(), "Budi", address))
+
+	if _, err := r.FindById(ctx, uuid.New()); !errors.Is(err, encrypt.ErrInvalidCiphertext) {
+		t.Fatalf("FindById() error = %v, want ErrInvalidCiphertext", err)
+	}
+}
+
+func TestFindUserReadsPlaintextRows(t *testing.T) {
+	r, mock, _ := newTestUserRepository(t)
+
+	// rows written before encryption was enabled are still readable
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE email = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "address", "phone_number"}).AddRow("Budi", "Jl. Merdeka 1", nil))
+
+	user, err := r.FindByEmail(ctx, "budi@example.com")
+	if err != nil {
+		t.Fatalf("FindByEmail() error = %v", err)
+	}
+
+	if user.Address != "Jl. Merdeka 1" || user.PhoneNumber != "" {
+		t.Fatalf("FindByEmail() = %q, %q, want the plaintext values", user.Address, user.PhoneNumber)
+	}
+}
+
+func TestUpdateUserEncryptsPII(t *testing.T) {
+	r, mock, blindIndex := newTestUserRepository(t)
+
+	updated := entity.UpdateUser(uuid.New(), "", "", "", "Jl. Sudirman 2", "+6289876543210", "")
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "address"=$1,"phone_number"=$2,"phone_number_index"=$3,"updated_at"=$4`)).
+		WithArgs(encryptedArg{"address", &updated.ID, "Jl. Sudirman 2"}, encryptedArg{"phone_number", &updated.ID, "+6289876543210"}, blindIndex.Index("+6289876543210"), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := r.UpdateUser(ctx, updated); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if updated.Address != "Jl. Sudirman 2" {
+		t.Fatalf("UpdateUser() left Address = %q, want the plaintext", updated.Address)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateUserPIIOnlyWritesUnchangedRows(t *testing.T) {
+	db, mock := newTestDB(t)
+	r := NewUserPIIRepository(db)
+
+	old := &entity.UserPII{ID: uuid.New(), Address: "Jl. Merdeka 1"}
+	updated := &entity.UserPII{ID: old.ID, Address: "enc:2:sealed"}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET address = NULLIF($1, '')`)).
+		WithArgs("enc:2:sealed", "", "", old.ID, "Jl. Merdeka 1", "").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := r.UpdateUserPII(ctx, old, updated)
+	if err != nil {
+		t.Fatalf("UpdateUserPII() error = %v", err)
+	}
+	if ok {
+		t.Fatal("UpdateUserPII() = true for a row changed meanwhile")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
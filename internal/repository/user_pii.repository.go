@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserPIIRepository reads and writes the stored, not decrypted, personal data of users. It bypasses the
+// encrypted serializer and soft deletes, deleted users still hold personal data.
+type UserPIIRepository interface {
+	FindUserPII(ctx context.Context, afterID uuid.UUID, limit int) ([]entity.UserPII, error)
+	UpdateUserPII(ctx context.Context, old *entity.UserPII, updated *entity.UserPII) (bool, error)
+}
+
+type userPIIRepository struct {
+	db *gorm.DB
+}
+
+func NewUserPIIRepository(db *gorm.DB) UserPIIRepository {
+	return &userPIIRepository{db}
+}
+
+// FindUserPII returns up to limit users ordered by id, starting after afterID.
+func (r *userPIIRepository) FindUserPII(ctx context.Context, afterID uuid.UUID, limit int) ([]entity.UserPII, error) {
+	users := make([]entity.UserPII, 0, limit)
+
+	if err := r.db.WithContext(ctx).Raw(`SELECT id, COALESCE(address, '') AS address, COALESCE(phone_number, '') AS phone_number,
+			COALESCE(phone_number_index, '') AS phone_number_index
+		FROM users WHERE id > ? ORDER BY id LIMIT ?`, afterID, limit).
+		Scan(&users).Error; err != nil {
+		return users, err
+	}
+
+	return users, nil
+}
+
+// UpdateUserPII only writes when the row still holds old, so a user updated meanwhile isn't overwritten. It
+// reports whether the row was updated.
+func (r *userPIIRepository) UpdateUserPII(ctx context.Context, old *entity.UserPII, updated *entity.UserPII) (bool, error) {
+	result := r.db.WithContext(ctx).Exec(`UPDATE users SET address = NULLIF(?, ''), phone_number = NULLIF(?, ''), phone_number_index = NULLIF(?, '')
+		WHERE id = ? AND COALESCE(address, '') = ? AND COALESCE(phone_number, '') = ?`,
+		updated.Address, updated.PhoneNumber, updated.PhoneNumberIndex, old.ID, old.Address, old.PhoneNumber)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrUserTokenInvalid = apperror.Validation("invalid_token", "token is invalid or has expired")
+
+type UserTokenRepository interface {
+	CreateUserToken(ctx context.Context, userToken *entity.UserToken) (*entity.UserToken, error)
+	ResetPassword(ctx context.Context, tokenHash string, hashedPassword string) (*entity.UserToken, error)
+	VerifyEmail(ctx context.Context, tokenHash string) (*entity.UserToken, error)
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return &userTokenRepository{db}
+}
+
+// CreateUserToken stores a new token and invalidates the unused ones of the same purpose, only the latest mail works.
+func (r *userTokenRepository) CreateUserToken(ctx context.Context, userToken *entity.UserToken) (*entity.UserToken, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userToken.UserID, userToken.Purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&userToken).Error
+	})
+
+	if err != nil {
+		return userToken, err
+	}
+
+	return userToken, nil
+}
+
+// ResetPassword consumes the reset token, sets the new password and logs the user out of every session.
+func (r *userTokenRepository) ResetPassword(ctx context.Context, tokenHash string, hashedPassword string) (*entity.UserToken, error) {
+	userToken := new(entity.UserToken)
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := consumeUserToken(tx, userToken, tokenHash, entity.UserTokenPasswordReset); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&entity.User{}).Where("id = ?", userToken.UserID).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&entity.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userToken.UserID).
+			Update("revoked_at", time.Now()).Error
+	})
+
+	if err != nil {
+		return userToken, err
+	}
+
+	return userToken, nil
+}
+
+func (r *userTokenRepository) VerifyEmail(ctx context.Context, tokenHash string) (*entity.UserToken, error) {
+	userToken := new(entity.UserToken)
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := consumeUserToken(tx, userToken, tokenHash, entity.UserTokenEmailVerification); err != nil {
+			return err
+		}
+
+		return tx.Model(&entity.User{}).Where("id = ?", userToken.UserID).Update("email_verified_at", time.Now()).Error
+	})
+
+	if err != nil {
+		return userToken, err
+	}
+
+	return userToken, nil
+}
+
+// consumeUserToken locks the token row so two requests can't use the same token, then marks it used.
+func consumeUserToken(tx *gorm.DB, userToken *entity.UserToken, tokenHash string, purpose string) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ? AND purpose = ?", tokenHash, purpose).
+		First(userToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserTokenInvalid
+		}
+		return err
+	}
+
+	if userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return ErrUserTokenInvalid
+	}
+
+	now := time.Now()
+	userToken.UsedAt = &now
+
+	return tx.Model(userToken).Update("used_at", now).Error
+}
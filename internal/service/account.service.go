@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/internal/repository"
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+	"github.com/DavidAfdal/workfinder/pkg/mailer"
+	"github.com/DavidAfdal/workfinder/pkg/token"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmailAlreadyVerified = apperror.Conflict("email_already_verified", "email already verified")
+	ErrEmailNotVerified     = apperror.Forbidden("email_not_verified", "please verify your email first")
+)
+
+type AccountService interface {
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, resetToken string, password string) error
+	RequestEmailVerification(ctx context.Context, userID uuid.UUID) error
+	VerifyEmail(ctx context.Context, verificationToken string) error
+}
+
+type accountService struct {
+	userRepo      repository.UserRepository
+	userTokenRepo repository.UserTokenRepository
+	mailer        mailer.Mailer
+	baseURL       string
+}
+
+func NewAccountService(userRepo repository.UserRepository, userTokenRepo repository.UserTokenRepository, mailer mailer.Mailer, baseURL string) AccountService {
+	return &accountService{userRepo, userTokenRepo, mailer, baseURL}
+}
+
+// RequestPasswordReset always succeeds for unknown emails so the endpoint can't be used to find accounts.
+func (s *accountService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	resetToken, err := s.createUserToken(ctx, user.ID, entity.UserTokenPasswordReset, entity.PasswordResetTokenExpire)
+
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your WorkFinder password",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link below to choose a new password. It expires in %s and works only once.\n\n%s\n\nIf you didn't ask for this, you can ignore this email.",
+			user.Name, entity.PasswordResetTokenExpire, s.link("/reset-password", resetToken)),
+	})
+}
+
+func (s *accountService) ResetPassword(ctx context.Context, resetToken string, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.userTokenRepo.ResetPassword(ctx, token.HashToken(resetToken), string(hashedPassword))
+
+	return err
+}
+
+func (s *accountService) RequestEmailVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.FindById(ctx, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if user.IsVerified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	verificationToken, err := s.createUserToken(ctx, user.ID, entity.UserTokenEmailVerification, entity.EmailVerificationTokenExpire)
+
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your WorkFinder email",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link below to verify your email. It expires in %s.\n\n%s",
+			user.Name, entity.EmailVerificationTokenExpire, s.link("/verify-email", verificationToken)),
+	})
+}
+
+func (s *accountService) VerifyEmail(ctx context.Context, verificationToken string) error {
+	_, err := s.userTokenRepo.VerifyEmail(ctx, token.HashToken(verificationToken))
+
+	return err
+}
+
+func (s *accountService) createUserToken(ctx context.Context, userID uuid.UUID, purpose string, expire time.Duration) (string, error) {
+	plainToken, err := token.GenerateOpaqueToken()
+
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := s.userTokenRepo.CreateUserToken(ctx, entity.NewUserToken(userID, purpose, token.HashToken(plainToken), expire)); err != nil {
+		return "", err
+	}
+
+	return plainToken, nil
+}
+
+func (s *accountService) link(path string, plainToken string) string {
+	return fmt.Sprintf("%s%s?token=%s", s.baseURL, path, url.QueryEscape(plainToken))
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var mailedTokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+func mailedToken(t *testing.T, m *fakeMailer) string {
+	t.Helper()
+
+	if len(m.messages) == 0 {
+		t.Fatalf("no email sent")
+	}
+
+	match := mailedTokenPattern.FindStringSubmatch(m.messages[len(m.messages)-1].Body)
+	if match == nil {
+		t.Fatalf("no token in email body")
+	}
+
+	plainToken, _ := url.QueryUnescape(match[1])
+	return plainToken
+}
+
+func TestAccountServiceResetPasswordIsSingleUse(t *testing.T) {
+	user := &entity.User{ID: uuid.New(), Email: "user@workfinder.id", Password: "old"}
+	userRepo := newFakeUserRepository(user)
+	userTokenRepo := newFakeUserTokenRepository(userRepo)
+	m := &fakeMailer{}
+	s := NewAccountService(userRepo, userTokenRepo, m, "http://localhost")
+
+	if err := s.RequestPasswordReset(ctx, "unknown@workfinder.id"); err != nil || len(m.messages) != 0 {
+		t.Fatalf("RequestPasswordReset() for unknown email = %v with %d mails, want nil and no mail", err, len(m.messages))
+	}
+
+	if err := s.RequestPasswordReset(ctx, user.Email); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	resetToken := mailedToken(t, m)
+
+	for hash := range userTokenRepo.userTokens {
+		if hash == resetToken {
+			t.Fatalf("reset token stored in plaintext")
+		}
+	}
+
+	if err := s.ResetPassword(ctx, resetToken, "new-password"); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new-password")) != nil {
+		t.Fatalf("ResetPassword() did not change the password")
+	}
+
+	if err := s.ResetPassword(ctx, resetToken, "another-password"); !errors.Is(err, repository.ErrUserTokenInvalid) {
+		t.Fatalf("ResetPassword() reusing token error = %v, want %v", err, repository.ErrUserTokenInvalid)
+	}
+}
+
+func TestAccountServiceVerifyEmail(t *testing.T) {
+	user := &entity.User{ID: uuid.New(), Email: "user@workfinder.id"}
+	userRepo := newFakeUserRepository(user)
+	m := &fakeMailer{}
+	s := NewAccountService(userRepo, newFakeUserTokenRepository(userRepo), m, "http://localhost")
+
+	if err := s.RequestEmailVerification(ctx, user.ID); err != nil {
+		t.Fatalf("RequestEmailVerification() error = %v", err)
+	}
+
+	if err := s.VerifyEmail(ctx, mailedToken(t, m)); err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+	if !user.IsVerified() {
+		t.Fatalf("VerifyEmail() did not verify the user")
+	}
+
+	if err := s.RequestEmailVerification(ctx, user.ID); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Fatalf("RequestEmailVerification() for verified user error = %v, want %v", err, ErrEmailAlreadyVerified)
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"github.com/DavidAfdal/workfinder/internal/entity"
 	"github.com/DavidAfdal/workfinder/internal/repository"
 	"github.com/google/uuid"
@@ -8,11 +9,11 @@ import (
 
 
 type CategoryService interface {
-	FindAllCategory() ([]entity.Category, error)
-	FindCategoryByID(id uuid.UUID) (*entity.Category, error)
-	CreateCategory(category *entity.Category) (*entity.Category, error)
-	UpdateCategory(category *entity.Category) (*entity.Category, error)
-	DeleteCategory(id uuid.UUID) (bool, error)
+	FindAllCategory(ctx context.Context) ([]entity.Category, error)
+	FindCategoryByID(ctx context.Context, id uuid.UUID) (*entity.Category, error)
+	CreateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error)
+	UpdateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error)
+	DeleteCategory(ctx context.Context, id uuid.UUID) (bool, error)
 }
 type categoryService struct {
 	categoryRepo repository.CategoryRepository
@@ -22,30 +23,30 @@ func NewCategoryService(categoryRepo repository.CategoryRepository) CategoryServ
 	return &categoryService{categoryRepo}
 }
 
-func (s *categoryService) FindAllCategory() ([]entity.Category, error) {
-	return s.categoryRepo.FindAllCategory()
+func (s *categoryService) FindAllCategory(ctx context.Context) ([]entity.Category, error) {
+	return s.categoryRepo.FindAllCategory(ctx)
 }
 
-func (s *categoryService) FindCategoryByID(id uuid.UUID) (*entity.Category, error) {
-	return s.categoryRepo.FindCategoryByID(id)
+func (s *categoryService) FindCategoryByID(ctx context.Context, id uuid.UUID) (*entity.Category, error) {
+	return s.categoryRepo.FindCategoryByID(ctx, id)
 }
 
-func (s *categoryService) CreateCategory(category *entity.Category) (*entity.Category, error) {
-	return s.categoryRepo.CreateCategory(category)
+func (s *categoryService) CreateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error) {
+	return s.categoryRepo.CreateCategory(ctx, category)
 }
 
-func (s *categoryService) UpdateCategory(category *entity.Category) (*entity.Category, error) {
-	return s.categoryRepo.UpdateCategory(category)
+func (s *categoryService) UpdateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error) {
+	return s.categoryRepo.UpdateCategory(ctx, category)
 }
 
-func (s *categoryService) DeleteCategory(id uuid.UUID) (bool, error) {
-	category, err := s.categoryRepo.FindCategoryByID(id)
+func (s *categoryService) DeleteCategory(ctx context.Context, id uuid.UUID) (bool, error) {
+	category, err := s.categoryRepo.FindCategoryByID(ctx, id)
 
 	if err != nil {
 		return false, err
 	}
 
-	return s.categoryRepo.DeleteCategory(category)
+	return s.categoryRepo.DeleteCategory(ctx, category)
 }
 
 
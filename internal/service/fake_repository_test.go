@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/internal/repository"
+	"github.com/DavidAfdal/workfinder/pkg/mailer"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ctx is what the tests pass to services and repositories.
+var ctx = context.Background()
+
+type fakeJobRepository struct {
+	jobs    map[uuid.UUID]*entity.Job
+	updated []*entity.Job
+	deleted []*entity.Job
+}
+
+func newFakeJobRepository(jobs ...*entity.Job) *fakeJobRepository {
+	repo := &fakeJobRepository{jobs: make(map[uuid.UUID]*entity.Job)}
+	for _, job := range jobs {
+		repo.jobs[job.ID] = job
+	}
+	return repo
+}
+
+func (r *fakeJobRepository) FindAllJob(ctx context.Context, filter *entity.JobFilter) (*entity.JobPage, error) {
+	page := &entity.JobPage{Jobs: make([]entity.Job, 0), Limit: filter.Limit}
+	for _, job := range r.jobs {
+		page.Jobs = append(page.Jobs, *job)
+	}
+	page.Total = int64(len(page.Jobs))
+	return page, nil
+}
+
+func (r *fakeJobRepository) SearchJobs(ctx context.Context, search *entity.JobSearch) (*entity.JobSearchPage, error) {
+	return &entity.JobSearchPage{Results: make([]entity.JobSearchResult, 0), Page: search.Page, Limit: search.Limit}, nil
+}
+
+func (r *fakeJobRepository) FindJobByID(ctx context.Context, id uuid.UUID) (*entity.Job, error) {
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return job, nil
+}
+
+func (r *fakeJobRepository) FindSharedJob(ctx context.Context, userId uuid.UUID) ([]entity.Job, error) {
+	jobs := make([]entity.Job, 0)
+	for _, job := range r.jobs {
+		if job.ClientID == userId {
+			jobs = append(jobs, *job)
+		}
+	}
+	return jobs, nil
+}
+
+func (r *fakeJobRepository) FindAppliedJob(ctx context.Context, userId uuid.UUID) ([]entity.Job, error) {
+	return make([]entity.Job, 0), nil
+}
+
+func (r *fakeJobRepository) CreateJob(ctx context.Context, job *entity.Job) (*entity.Job, error) {
+	job.ID = uuid.New()
+	r.jobs[job.ID] = job
+	return job, nil
+}
+
+func (r *fakeJobRepository) UpdateJob(ctx context.Context, job *entity.Job, actorID uuid.UUID) (*entity.Job, error) {
+	r.updated = append(r.updated, job)
+	return job, nil
+}
+
+func (r *fakeJobRepository) DeleteJob(ctx context.Context, job *entity.Job) (bool, error) {
+	r.deleted = append(r.deleted, job)
+	delete(r.jobs, job.ID)
+	return true, nil
+}
+
+type fakeJobApplicantsRepository struct {
+	jobApplicants map[uuid.UUID]*entity.JobApplicants
+	history       []entity.ApplicationStatusHistory
+	approved      []*entity.JobApplicants
+}
+
+func newFakeJobApplicantsRepository(jobApplicants ...*entity.JobApplicants) *fakeJobApplicantsRepository {
+	repo := &fakeJobApplicantsRepository{jobApplicants: make(map[uuid.UUID]*entity.JobApplicants)}
+	for _, jobApplicant := range jobApplicants {
+		repo.jobApplicants[jobApplicant.ID] = jobApplicant
+	}
+	return repo
+}
+
+func (r *fakeJobApplicantsRepository) ApplyJob(ctx context.Context, jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error) {
+	jobApplicant.ID = uuid.New()
+	r.jobApplicants[jobApplicant.ID] = jobApplicant
+	r.history = append(r.history, *entity.NewApplicationStatusHistory(jobApplicant.ID, "", jobApplicant.Status, jobApplicant.ApplicantID, ""))
+	return jobApplicant, nil
+}
+
+func (r *fakeJobApplicantsRepository) ReapplyJob(ctx context.Context, withdrawn *entity.JobApplicants, jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error) {
+	delete(r.jobApplicants, withdrawn.ID)
+	return r.ApplyJob(ctx, jobApplicant)
+}
+
+func (r *fakeJobApplicantsRepository) FindApplication(ctx context.Context, jobID uuid.UUID, applicantID uuid.UUID) (*entity.JobApplicants, error) {
+	for _, jobApplicant := range r.jobApplicants {
+		if jobApplicant.JobID == jobID && jobApplicant.ApplicantID == applicantID {
+			return jobApplicant, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeJobApplicantsRepository) FindJobApplicantsByID(ctx context.Context, id uuid.UUID) (*entity.JobApplicants, error) {
+	jobApplicant, ok := r.jobApplicants[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return jobApplicant, nil
+}
+
+func (r *fakeJobApplicantsRepository) UpdateStatus(ctx context.Context, jobApplicant *entity.JobApplicants, toStatus string, actorID uuid.UUID, reason string) (*entity.JobApplicants, error) {
+	r.history = append(r.history, *entity.NewApplicationStatusHistory(jobApplicant.ID, jobApplicant.Status, toStatus, actorID, reason))
+	jobApplicant.Status = toStatus
+	return jobApplicant, nil
+}
+
+func (r *fakeJobApplicantsRepository) FindStatusHistory(ctx context.Context, jobApplicantID uuid.UUID) ([]entity.ApplicationStatusHistory, error) {
+	history := make([]entity.ApplicationStatusHistory, 0)
+	for _, h := range r.history {
+		if h.JobApplicantID == jobApplicantID {
+			history = append(history, h)
+		}
+	}
+	return history, nil
+}
+
+func (r *fakeJobApplicantsRepository) ApproveApplicant(ctx context.Context, jobApplicants *entity.JobApplicants, actorID uuid.UUID, rejectionMessage string) (*entity.JobApplicants, error) {
+	r.approved = append(r.approved, jobApplicants)
+	return r.UpdateStatus(ctx, jobApplicants, entity.ApplicationHired, actorID, "")
+}
+
+type fakeUserRepository struct {
+	users   map[uuid.UUID]*entity.User
+	updated []*entity.User
+}
+
+func newFakeUserRepository(users ...*entity.User) *fakeUserRepository {
+	repo := &fakeUserRepository{users: make(map[uuid.UUID]*entity.User)}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepository) FindAllUser(ctx context.Context) ([]entity.User, error) {
+	users := make([]entity.User, 0)
+	for _, user := range r.users {
+		users = append(users, *user)
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) FindByPhoneNumber(ctx context.Context, phoneNumber string) (*entity.User, error) {
+	for _, user := range r.users {
+		if user.PhoneNumber == phoneNumber {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) FindById(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) CreateUser(ctx context.Context, user *entity.User) (*entity.User, error) {
+	user.ID = uuid.New()
+	r.users[user.ID] = user
+	return user, nil
+}
+
+func (r *fakeUserRepository) UpdateUser(ctx context.Context, user *entity.User) (*entity.User, error) {
+	r.updated = append(r.updated, user)
+	return user, nil
+}
+
+func (r *fakeUserRepository) DeleteUser(ctx context.Context, user *entity.User) (bool, error) {
+	delete(r.users, user.ID)
+	return true, nil
+}
+
+type fakeRefreshTokenRepository struct {
+	refreshTokens map[uuid.UUID]*entity.RefreshToken
+}
+
+func newFakeRefreshTokenRepository() *fakeRefreshTokenRepository {
+	return &fakeRefreshTokenRepository{refreshTokens: make(map[uuid.UUID]*entity.RefreshToken)}
+}
+
+func (r *fakeRefreshTokenRepository) CreateRefreshToken(ctx context.Context, refreshToken *entity.RefreshToken) (*entity.RefreshToken, error) {
+	r.refreshTokens[refreshToken.ID] = refreshToken
+	return refreshToken, nil
+}
+
+func (r *fakeRefreshTokenRepository) FindRefreshTokenByHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	for _, refreshToken := range r.refreshTokens {
+		if refreshToken.TokenHash == tokenHash {
+			return refreshToken, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRefreshTokenRepository) RotateRefreshToken(ctx context.Context, oldToken *entity.RefreshToken, newToken *entity.RefreshToken) (*entity.RefreshToken, error) {
+	if oldToken.IsRevoked() {
+		return nil, repository.ErrRefreshTokenAlreadyRotated
+	}
+	now := time.Now()
+	oldToken.RevokedAt = &now
+	oldToken.ReplacedByID = &newToken.ID
+	r.refreshTokens[newToken.ID] = newToken
+	return newToken, nil
+}
+
+func (r *fakeRefreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	now := time.Now()
+	for _, refreshToken := range r.refreshTokens {
+		if refreshToken.FamilyID == familyID && !refreshToken.IsRevoked() {
+			refreshToken.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+type fakeDenylist struct {
+	jtis map[string]time.Duration
+}
+
+func newFakeDenylist() *fakeDenylist {
+	return &fakeDenylist{jtis: make(map[string]time.Duration)}
+}
+
+func (d *fakeDenylist) Add(ctx context.Context, jti string, expire time.Duration) error {
+	d.jtis[jti] = expire
+	return nil
+}
+
+func (d *fakeDenylist) Contains(ctx context.Context, jti string) bool {
+	_, ok := d.jtis[jti]
+	return ok
+}
+
+type fakeUserTokenRepository struct {
+	userRepo   *fakeUserRepository
+	userTokens map[string]*entity.UserToken
+}
+
+func newFakeUserTokenRepository(userRepo *fakeUserRepository) *fakeUserTokenRepository {
+	return &fakeUserTokenRepository{userRepo: userRepo, userTokens: make(map[string]*entity.UserToken)}
+}
+
+func (r *fakeUserTokenRepository) CreateUserToken(ctx context.Context, userToken *entity.UserToken) (*entity.UserToken, error) {
+	userToken.ID = uuid.New()
+	r.userTokens[userToken.TokenHash] = userToken
+	return userToken, nil
+}
+
+func (r *fakeUserTokenRepository) consume(tokenHash string, purpose string) (*entity.UserToken, error) {
+	userToken, ok := r.userTokens[tokenHash]
+	if !ok || userToken.Purpose != purpose || userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return nil, repository.ErrUserTokenInvalid
+	}
+	now := time.Now()
+	userToken.UsedAt = &now
+	return userToken, nil
+}
+
+func (r *fakeUserTokenRepository) ResetPassword(ctx context.Context, tokenHash string, hashedPassword string) (*entity.UserToken, error) {
+	userToken, err := r.consume(tokenHash, entity.UserTokenPasswordReset)
+	if err != nil {
+		return nil, err
+	}
+	r.userRepo.users[userToken.UserID].Password = hashedPassword
+	return userToken, nil
+}
+
+func (r *fakeUserTokenRepository) VerifyEmail(ctx context.Context, tokenHash string) (*entity.UserToken, error) {
+	userToken, err := r.consume(tokenHash, entity.UserTokenEmailVerification)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	r.userRepo.users[userToken.UserID].EmailVerifiedAt = &now
+	return userToken, nil
+}
+
+type fakeMailer struct {
+	messages []mailer.Message
+}
+
+func (m *fakeMailer) Send(ctx context.Context, message mailer.Message) error {
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+type fakeUserPIIRepository struct {
+	users []entity.UserPII
+	// changed simulates users updated by the app while they are being rotated
+	changed map[uuid.UUID]bool
+}
+
+func (r *fakeUserPIIRepository) FindUserPII(ctx context.Context, afterID uuid.UUID, limit int) ([]entity.UserPII, error) {
+	users := make([]entity.UserPII, 0, limit)
+	for _, user := range r.users {
+		if user.ID.String() > afterID.String() && len(users) < limit {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserPIIRepository) UpdateUserPII(ctx context.Context, old *entity.UserPII, updated *entity.UserPII) (bool, error) {
+	if r.changed[old.ID] {
+		return false, nil
+	}
+	for i := range r.users {
+		if r.users[i].ID == old.ID {
+			r.users[i] = *updated
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"github.com/DavidAfdal/workfinder/internal/entity"
 	"github.com/DavidAfdal/workfinder/internal/repository"
+	"github.com/DavidAfdal/workfinder/pkg/metrics"
 	"github.com/google/uuid"
 )
 
@@ -12,54 +14,94 @@ import (
 
 
 type JobService interface {
-	FindAllJob() ([]entity.Job, error)
-	FindJobByID(id uuid.UUID) (*entity.Job, error)
-	FindSharedJobs(userID uuid.UUID) ([]entity.Job, error)
-	FindAppliedJobs(userID uuid.UUID) ([]entity.Job, error)
-	CreateJob(job *entity.Job) (*entity.Job, error)
-	UpdateJob(job *entity.Job) (*entity.Job, error)
-	DeleteJob(id uuid.UUID) (bool, error)
+	FindAllJob(ctx context.Context, filter *entity.JobFilter) (*entity.JobPage, error)
+	SearchJobs(ctx context.Context, search *entity.JobSearch) (*entity.JobSearchPage, error)
+	FindJobByID(ctx context.Context, id uuid.UUID) (*entity.Job, error)
+	FindSharedJobs(ctx context.Context, userID uuid.UUID) ([]entity.Job, error)
+	FindAppliedJobs(ctx context.Context, userID uuid.UUID) ([]entity.Job, error)
+	CreateJob(ctx context.Context, job *entity.Job) (*entity.Job, error)
+	UpdateJob(ctx context.Context, actor Actor, job *entity.Job) (*entity.Job, error)
+	DeleteJob(ctx context.Context, actor Actor, id uuid.UUID) (bool, error)
 }
 
 type jobService struct {
-	jobRepo repository.JobRepository
+	jobRepo  repository.JobRepository
+	userRepo repository.UserRepository
+	policy   Policy
+	metrics  *metrics.Metrics
 }
 
 
-func NewJobService(jobRepo repository.JobRepository) JobService {
-	return &jobService{jobRepo: jobRepo}
+func NewJobService(jobRepo repository.JobRepository, userRepo repository.UserRepository, policy Policy, metrics *metrics.Metrics) JobService {
+	return &jobService{jobRepo: jobRepo, userRepo: userRepo, policy: policy, metrics: metrics}
 }
 
 
-func (s *jobService) FindAllJob() ([]entity.Job, error) {
-	return s.jobRepo.FindAllJob()
+func (s *jobService) FindAllJob(ctx context.Context, filter *entity.JobFilter) (*entity.JobPage, error) {
+	return s.jobRepo.FindAllJob(ctx, filter)
 }
 
+func (s *jobService) SearchJobs(ctx context.Context, search *entity.JobSearch) (*entity.JobSearchPage, error) {
+	return s.jobRepo.SearchJobs(ctx, search)
+}
 
-func (s *jobService) FindJobByID(id uuid.UUID) (*entity.Job, error) {
-	return s.jobRepo.FindJobByID(id)
+func (s *jobService) FindJobByID(ctx context.Context, id uuid.UUID) (*entity.Job, error) {
+	return s.jobRepo.FindJobByID(ctx, id)
 }
 
-func (s *jobService) FindSharedJobs(userID uuid.UUID) ([]entity.Job, error) {
-	return s.jobRepo.FindSharedJob(userID)
+func (s *jobService) FindSharedJobs(ctx context.Context, userID uuid.UUID) ([]entity.Job, error) {
+	return s.jobRepo.FindSharedJob(ctx, userID)
 }
-func (s *jobService) FindAppliedJobs(userID uuid.UUID) ([]entity.Job, error) {
-	return s.jobRepo.FindAppliedJob(userID)
+func (s *jobService) FindAppliedJobs(ctx context.Context, userID uuid.UUID) ([]entity.Job, error) {
+	return s.jobRepo.FindAppliedJob(ctx, userID)
 }
-func (s *jobService) CreateJob(job *entity.Job) (*entity.Job, error) {
-	return s.jobRepo.CreateJob(job)
+func (s *jobService) CreateJob(ctx context.Context, job *entity.Job) (*entity.Job, error) {
+	client, err := s.userRepo.FindById(ctx, job.ClientID)
+
+	if err != nil {
+		return job, err
+	}
+
+	if !client.IsVerified() {
+		return job, ErrEmailNotVerified
+	}
+
+	job, err = s.jobRepo.CreateJob(ctx, job)
+	if err != nil {
+		return job, err
+	}
+
+	s.metrics.JobsPosted.Inc()
+
+	return job, nil
 }
 
-func (s *jobService) UpdateJob(job *entity.Job) (*entity.Job, error) {
-	return s.jobRepo.UpdateJob(job)
+func (s *jobService) UpdateJob(ctx context.Context, actor Actor, job *entity.Job) (*entity.Job, error) {
+	existingJob, err := s.jobRepo.FindJobByID(ctx, job.ID)
+
+	if err != nil {
+		return job, err
+	}
+
+	if err := s.policy.CanManageJob(actor, existingJob); err != nil {
+		return job, err
+	}
+
+	job.ClientID = existingJob.ClientID
+
+	return s.jobRepo.UpdateJob(ctx, job, actor.ID)
 }
 
-func (s *jobService) DeleteJob(id uuid.UUID)  (bool, error) {
-	job, err := s.jobRepo.FindJobByID(id)
+func (s *jobService) DeleteJob(ctx context.Context, actor Actor, id uuid.UUID)  (bool, error) {
+	job, err := s.jobRepo.FindJobByID(ctx, id)
 
 	if err != nil {
 		return false, err
 	}
 
-	return s.jobRepo.DeleteJob(job)
+	if err := s.policy.CanManageJob(actor, job); err != nil {
+		return false, err
+	}
+
+	return s.jobRepo.DeleteJob(ctx, job)
 }
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/pkg/metrics"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newTestMetrics() *metrics.Metrics {
+	return metrics.NewMetrics(prometheus.NewRegistry())
+}
+
+func TestJobServiceUpdateJobOwnership(t *testing.T) {
+	owner := NewActor(uuid.New(), entity.RoleClient)
+	job := &entity.Job{ID: uuid.New(), Title: "Backend Engineer", ClientID: owner.ID}
+
+	tests := []struct {
+		name    string
+		actor   Actor
+		wantErr error
+	}{
+		{name: "owner", actor: owner},
+		{name: "admin", actor: NewActor(uuid.New(), entity.RoleAdmin)},
+		{name: "other client", actor: NewActor(uuid.New(), entity.RoleClient), wantErr: ErrForbidden},
+		{name: "applicant", actor: NewActor(uuid.New(), entity.RoleApplicant), wantErr: ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeJobRepository(job)
+			s := NewJobService(repo, newFakeUserRepository(), NewPolicy(), newTestMetrics())
+
+			_, err := s.UpdateJob(ctx, tt.actor, &entity.Job{ID: job.ID, Title: "Senior Backend Engineer"})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateJob() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && len(repo.updated) != 0 {
+				t.Fatalf("UpdateJob() updated the job of another client")
+			}
+		})
+	}
+}
+
+func TestJobServiceDeleteJobOwnership(t *testing.T) {
+	owner := NewActor(uuid.New(), entity.RoleClient)
+	job := &entity.Job{ID: uuid.New(), ClientID: owner.ID}
+
+	repo := newFakeJobRepository(job)
+	s := NewJobService(repo, newFakeUserRepository(), NewPolicy(), newTestMetrics())
+
+	if _, err := s.DeleteJob(ctx, NewActor(uuid.New(), entity.RoleClient), job.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("DeleteJob() by another client error = %v, want %v", err, ErrForbidden)
+	}
+	if len(repo.deleted) != 0 {
+		t.Fatalf("DeleteJob() deleted the job of another client")
+	}
+
+	isDeleted, err := s.DeleteJob(ctx, owner, job.ID)
+	if err != nil || !isDeleted {
+		t.Fatalf("DeleteJob() by owner = %v, %v, want true, nil", isDeleted, err)
+	}
+}
+
+func TestJobServiceCreateJobRequiresVerifiedEmail(t *testing.T) {
+	verifiedAt := time.Now()
+	unverified := &entity.User{ID: uuid.New(), Role: entity.RoleClient}
+	verified := &entity.User{ID: uuid.New(), Role: entity.RoleClient, EmailVerifiedAt: &verifiedAt}
+
+	m := newTestMetrics()
+	s := NewJobService(newFakeJobRepository(), newFakeUserRepository(unverified, verified), NewPolicy(), m)
+
+	if _, err := s.CreateJob(ctx, &entity.Job{Title: "Backend Engineer", ClientID: unverified.ID}); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("CreateJob() by unverified client error = %v, want %v", err, ErrEmailNotVerified)
+	}
+	if _, err := s.CreateJob(ctx, &entity.Job{Title: "Backend Engineer", ClientID: verified.ID}); err != nil {
+		t.Fatalf("CreateJob() by verified client error = %v", err)
+	}
+
+	if posted := testutil.ToFloat64(m.JobsPosted); posted != 1 {
+		t.Fatalf("jobs posted = %v, want only the verified client's job counted", posted)
+	}
+}
@@ -1,97 +1,215 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/DavidAfdal/workfinder/internal/entity"
 	"github.com/DavidAfdal/workfinder/internal/repository"
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+	"github.com/DavidAfdal/workfinder/pkg/metrics"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type JobApplicantService interface {
-	ApplyJob(jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error)
-	WithdrawJob(id uuid.UUID, userID uuid.UUID) (bool, error)
-	ApproveApplicant(id uuid.UUID, userID uuid.UUID) (*entity.JobApplicants, error)
-	FindJobApplicantByID(id uuid.UUID) (*entity.JobApplicants, error)
+	ApplyJob(ctx context.Context, jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error)
+	WithdrawJob(ctx context.Context, actor Actor, id uuid.UUID) (bool, error)
+	ApproveApplicant(ctx context.Context, actor Actor, id uuid.UUID, rejectionMessage string) (*entity.JobApplicants, error)
+	UpdateStatus(ctx context.Context, actor Actor, id uuid.UUID, status string, reason string) (*entity.JobApplicants, error)
+	FindStatusHistory(ctx context.Context, actor Actor, id uuid.UUID) ([]entity.ApplicationStatusHistory, error)
+	FindJobApplicantByID(ctx context.Context, actor Actor, id uuid.UUID) (*entity.JobApplicants, error)
+}
+
+var (
+	ErrJobNotFound             = apperror.NotFound("job_not_found", "job not found")
+	ErrApplyOwnJob             = apperror.Forbidden("apply_own_job", "you can't apply for your own job")
+	ErrApproveSelf             = apperror.Forbidden("approve_self", "can't approve yourself")
+	ErrInvalidStatusTransition = apperror.Conflict("invalid_status_transition", "invalid application status transition")
+	ErrReapplyNotAllowed       = apperror.Conflict("reapply_not_allowed", "you can't apply again for this job yet")
+)
+
+// ReapplyPolicy decides whether a withdrawn application can be replaced by a new one.
+type ReapplyPolicy struct {
+	Allowed  bool
+	Cooldown time.Duration
+}
+
+func NewReapplyPolicy(allowed bool, cooldown time.Duration) ReapplyPolicy {
+	return ReapplyPolicy{Allowed: allowed, Cooldown: cooldown}
+}
+
+// CanReapply reports whether the applicant may apply again, the cooldown starts when the application was withdrawn.
+func (p ReapplyPolicy) CanReapply(withdrawn *entity.JobApplicants, now time.Time) bool {
+	return p.Allowed && !now.Before(withdrawn.UpdatedAt.Add(p.Cooldown))
 }
 
 type jobApplicantService struct {
 	jobApplicantRepo repository.JobApplicantsRepository
 	jobRepo          repository.JobRepository
+	policy           Policy
+	reapplyPolicy    ReapplyPolicy
+	metrics          *metrics.Metrics
 }
 
-func NewJobApplicantService(jobApplicantRepo repository.JobApplicantsRepository, jobRepo repository.JobRepository) JobApplicantService {
-	return &jobApplicantService{jobApplicantRepo,jobRepo}
+func NewJobApplicantService(jobApplicantRepo repository.JobApplicantsRepository, jobRepo repository.JobRepository, policy Policy, reapplyPolicy ReapplyPolicy, metrics *metrics.Metrics) JobApplicantService {
+	return &jobApplicantService{jobApplicantRepo, jobRepo, policy, reapplyPolicy, metrics}
 }
 
-func (s *jobApplicantService) ApplyJob(jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error) {
+func (s *jobApplicantService) ApplyJob(ctx context.Context, jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error) {
+	applied, err := s.applyJob(ctx, jobApplicant)
+	if err != nil {
+		return applied, err
+	}
+
+	s.metrics.ApplicationsSubmitted.Inc()
+
+	return applied, nil
+}
+
+func (s *jobApplicantService) applyJob(ctx context.Context, jobApplicant *entity.JobApplicants) (*entity.JobApplicants, error) {
+
+	job, err := s.jobRepo.FindJobByID(ctx, jobApplicant.JobID)
 
-	job, err := s.jobRepo.FindJobByID(jobApplicant.JobID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobNotFound
+	}
 
 	if err != nil {
-		return nil, errors.New("job not found")
+		return nil, err
 	}
 
 	if job.Closed == true {
-		return nil, errors.New("job already closed")
+		return nil, repository.ErrJobClosed
 	}
 
 	if jobApplicant.ApplicantID == job.ClientID {
-		return nil, errors.New("you can't apply for your own job")
+		return nil, ErrApplyOwnJob
 	}
 
-	jobApplicantData, _ := s.jobApplicantRepo.FindJobApplicantsByID(jobApplicant.ID)
+	existing, err := s.jobApplicantRepo.FindApplication(ctx, jobApplicant.JobID, jobApplicant.ApplicantID)
 
-	if jobApplicantData != nil {
-		return jobApplicantData, errors.New("job already applied")
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.jobApplicantRepo.ApplyJob(ctx, jobApplicant)
 	}
 
-	return s.jobApplicantRepo.ApplyJob(jobApplicant)
-}
+	if err != nil {
+		return nil, err
+	}
 
-func (s *jobApplicantService) WithdrawJob(id uuid.UUID, userID uuid.UUID) (bool, error) {
-	jobApplicant, err := s.jobApplicantRepo.FindJobApplicantsByID(id)
+	if existing.Status != entity.ApplicationWithdrawn {
+		return nil, repository.ErrAlreadyApplied
+	}
 
-	if err != nil {
-		return false, err
+	if !s.reapplyPolicy.CanReapply(existing, time.Now()) {
+		return nil, ErrReapplyNotAllowed
 	}
 
-	if jobApplicant.ApplicantID != userID {
-		return false, errors.New("unauthorized")
+	return s.jobApplicantRepo.ReapplyJob(ctx, existing, jobApplicant)
+}
+
+func (s *jobApplicantService) WithdrawJob(ctx context.Context, actor Actor, id uuid.UUID) (bool, error) {
+	if _, err := s.UpdateStatus(ctx, actor, id, entity.ApplicationWithdrawn, ""); err != nil {
+		return false, err
 	}
 
-	return s.jobApplicantRepo.WithdrawJob(jobApplicant)
+	return true, nil
+}
+
+// ApproveApplicant hires the applicant, rejectionMessage is sent to the remaining applicants once the headcount is filled.
+func (s *jobApplicantService) ApproveApplicant(ctx context.Context, actor Actor, id uuid.UUID, rejectionMessage string) (*entity.JobApplicants, error) {
+	return s.updateStatus(ctx, actor, id, entity.ApplicationHired, "", rejectionMessage)
 }
 
-func (s *jobApplicantService) ApproveApplicant(id uuid.UUID, userID uuid.UUID) (*entity.JobApplicants, error) {
+// UpdateStatus moves an application through its lifecycle. Only the applicant can withdraw, every other
+// transition belongs to the job owner.
+func (s *jobApplicantService) UpdateStatus(ctx context.Context, actor Actor, id uuid.UUID, status string, reason string) (*entity.JobApplicants, error) {
+	return s.updateStatus(ctx, actor, id, status, reason, "")
+}
+
+func (s *jobApplicantService) updateStatus(ctx context.Context, actor Actor, id uuid.UUID, status string, reason string, rejectionMessage string) (*entity.JobApplicants, error) {
+	jobApplicant, err := s.jobApplicantRepo.FindJobApplicantsByID(ctx, id)
+
+	if err != nil {
+		return jobApplicant, err
+	}
 
+	job, err := s.jobRepo.FindJobByID(ctx, jobApplicant.JobID)
 
-	jobApplicant, err := s.jobApplicantRepo.FindJobApplicantsByID(id)
+	if err != nil {
+		return jobApplicant, err
+	}
 
-	job, err := s.jobRepo.FindJobByID(jobApplicant.JobID)
+	if status == entity.ApplicationWithdrawn {
+		err = s.policy.CanWithdrawApplication(actor, jobApplicant)
+	} else {
+		err = s.policy.CanReviewApplication(actor, job)
+	}
 
 	if err != nil {
 		return jobApplicant, err
 	}
 
+	if !entity.IsApplicationStatus(jobApplicant.Status) {
+		return jobApplicant, fmt.Errorf("application %s has the unknown status %q", jobApplicant.ID, jobApplicant.Status)
+	}
+
+	if entity.IsFinalApplicationStatus(jobApplicant.Status) {
+		return jobApplicant, ErrInvalidStatusTransition.WithMessage(fmt.Sprintf("application is already %s, it can't change anymore", jobApplicant.Status))
+	}
+
+	if !entity.CanTransitionApplication(jobApplicant.Status, status) {
+		return jobApplicant, ErrInvalidStatusTransition.WithMessage(fmt.Sprintf("invalid application status transition from %s to %s", jobApplicant.Status, status))
+	}
+
+	if status != entity.ApplicationHired {
+		return s.jobApplicantRepo.UpdateStatus(ctx, jobApplicant, status, actor.ID, reason)
+	}
 
 	if job.Closed == true {
-		return jobApplicant, errors.New("job already closed")
+		return jobApplicant, repository.ErrJobClosed
 	}
 
+	if jobApplicant.ApplicantID == actor.ID {
+		return jobApplicant, ErrApproveSelf
+	}
 
-	if job.Client.ID != userID {
-		return jobApplicant, errors.New("unauthorized")
+	approved, err := s.jobApplicantRepo.ApproveApplicant(ctx, jobApplicant, actor.ID, rejectionMessage)
+	if err != nil {
+		return approved, err
 	}
 
-	if jobApplicant.ApplicantID == userID {
-		return jobApplicant, errors.New("can't approve yourself")
+	s.metrics.ApplicationsApproved.Inc()
+
+	return approved, nil
+}
+
+func (s *jobApplicantService) FindStatusHistory(ctx context.Context, actor Actor, id uuid.UUID) ([]entity.ApplicationStatusHistory, error) {
+	if _, err := s.FindJobApplicantByID(ctx, actor, id); err != nil {
+		return nil, err
 	}
 
-	return s.jobApplicantRepo.ApproveApplicant(jobApplicant)
+	return s.jobApplicantRepo.FindStatusHistory(ctx, id)
 }
 
+func (s *jobApplicantService) FindJobApplicantByID(ctx context.Context, actor Actor, id uuid.UUID) (*entity.JobApplicants, error) {
+	jobApplicant, err := s.jobApplicantRepo.FindJobApplicantsByID(ctx, id)
+
+	if err != nil {
+		return jobApplicant, err
+	}
+
+	job, err := s.jobRepo.FindJobByID(ctx, jobApplicant.JobID)
+
+	if err != nil {
+		return jobApplicant, err
+	}
+
+	if err := s.policy.CanViewApplication(actor, jobApplicant, job); err != nil {
+		return nil, err
+	}
 
-func (s *jobApplicantService) FindJobApplicantByID(id uuid.UUID) (*entity.JobApplicants, error) {
-	return s.jobApplicantRepo.FindJobApplicantsByID(id)
+	return jobApplicant, nil
 }
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/internal/repository"
+	"github.com/google/uuid"
+)
+
+func TestJobApplicantServiceOwnership(t *testing.T) {
+	client := NewActor(uuid.New(), entity.RoleClient)
+	applicant := NewActor(uuid.New(), entity.RoleApplicant)
+	stranger := NewActor(uuid.New(), entity.RoleApplicant)
+
+	job := &entity.Job{ID: uuid.New(), ClientID: client.ID}
+
+	newService := func(status string) (JobApplicantService, *fakeJobApplicantsRepository, *entity.JobApplicants) {
+		jobApplicant := &entity.JobApplicants{ID: uuid.New(), JobID: job.ID, ApplicantID: applicant.ID, Status: status}
+		repo := newFakeJobApplicantsRepository(jobApplicant)
+		return NewJobApplicantService(repo, newFakeJobRepository(job), NewPolicy(), NewReapplyPolicy(true, 0), newTestMetrics()), repo, jobApplicant
+	}
+
+	t.Run("only the applicant can withdraw", func(t *testing.T) {
+		s, _, jobApplicant := newService(entity.ApplicationWaiting)
+
+		if _, err := s.WithdrawJob(ctx, client, jobApplicant.ID); !errors.Is(err, ErrForbidden) {
+			t.Fatalf("WithdrawJob() by job owner error = %v, want %v", err, ErrForbidden)
+		}
+		if _, err := s.WithdrawJob(ctx, applicant, jobApplicant.ID); err != nil {
+			t.Fatalf("WithdrawJob() by applicant error = %v", err)
+		}
+		if jobApplicant.Status != entity.ApplicationWithdrawn {
+			t.Fatalf("status = %s, want %s", jobApplicant.Status, entity.ApplicationWithdrawn)
+		}
+	})
+
+	t.Run("only the job owner can approve", func(t *testing.T) {
+		s, repo, jobApplicant := newService(entity.ApplicationOffered)
+
+		if _, err := s.ApproveApplicant(ctx, stranger, jobApplicant.ID, ""); !errors.Is(err, ErrForbidden) {
+			t.Fatalf("ApproveApplicant() by stranger error = %v, want %v", err, ErrForbidden)
+		}
+		if _, err := s.ApproveApplicant(ctx, client, jobApplicant.ID, ""); err != nil {
+			t.Fatalf("ApproveApplicant() by job owner error = %v", err)
+		}
+		if len(repo.approved) != 1 {
+			t.Fatalf("approved = %d, want 1", len(repo.approved))
+		}
+	})
+
+	t.Run("applicant and job owner can view", func(t *testing.T) {
+		s, _, jobApplicant := newService(entity.ApplicationWaiting)
+
+		for _, actor := range []Actor{applicant, client, NewActor(uuid.New(), entity.RoleAdmin)} {
+			if _, err := s.FindJobApplicantByID(ctx, actor, jobApplicant.ID); err != nil {
+				t.Fatalf("FindJobApplicantByID() by %s error = %v", actor.Role, err)
+			}
+		}
+		if _, err := s.FindJobApplicantByID(ctx, stranger, jobApplicant.ID); !errors.Is(err, ErrForbidden) {
+			t.Fatalf("FindJobApplicantByID() by stranger error = %v, want %v", err, ErrForbidden)
+		}
+	})
+}
+
+func TestJobApplicantServiceStatusLifecycle(t *testing.T) {
+	client := NewActor(uuid.New(), entity.RoleClient)
+	applicant := NewActor(uuid.New(), entity.RoleApplicant)
+
+	job := &entity.Job{ID: uuid.New(), ClientID: client.ID}
+	jobApplicant := &entity.JobApplicants{ID: uuid.New(), JobID: job.ID, ApplicantID: applicant.ID, Status: entity.ApplicationWaiting}
+
+	repo := newFakeJobApplicantsRepository(jobApplicant)
+	s := NewJobApplicantService(repo, newFakeJobRepository(job), NewPolicy(), NewReapplyPolicy(true, 0), newTestMetrics())
+
+	if _, err := s.ApproveApplicant(ctx, client, jobApplicant.ID, ""); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatalf("hiring a waiting application error = %v, want %v", err, ErrInvalidStatusTransition)
+	}
+
+	for _, status := range []string{entity.ApplicationReviewed, entity.ApplicationShortlisted, entity.ApplicationInterview, entity.ApplicationOffered} {
+		if _, err := s.UpdateStatus(ctx, client, jobApplicant.ID, status, "moving on"); err != nil {
+			t.Fatalf("UpdateStatus(%s) error = %v", status, err)
+		}
+	}
+
+	if _, err := s.UpdateStatus(ctx, applicant, jobApplicant.ID, entity.ApplicationHired, ""); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("applicant hiring themselves error = %v, want %v", err, ErrForbidden)
+	}
+
+	if _, err := s.ApproveApplicant(ctx, client, jobApplicant.ID, ""); err != nil {
+		t.Fatalf("ApproveApplicant() error = %v", err)
+	}
+
+	_, err := s.WithdrawJob(ctx, applicant, jobApplicant.ID)
+	if !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatalf("withdrawing a hired application error = %v, want %v", err, ErrInvalidStatusTransition)
+	}
+	if err.Error() != "application is already Hired, it can't change anymore" {
+		t.Fatalf("withdrawing a hired application error = %q, want it to name the final status", err)
+	}
+
+	legacy := &entity.JobApplicants{ID: uuid.New(), JobID: job.ID, ApplicantID: applicant.ID, Status: "Approved"}
+	repo.jobApplicants[legacy.ID] = legacy
+	if _, err := s.WithdrawJob(ctx, applicant, legacy.ID); err == nil || errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatalf("withdrawing an application with an unknown status error = %v, want an internal error", err)
+	}
+
+	history, err := s.FindStatusHistory(ctx, applicant, jobApplicant.ID)
+	if err != nil {
+		t.Fatalf("FindStatusHistory() error = %v", err)
+	}
+	if len(history) != 5 || history[4].ToStatus != entity.ApplicationHired || history[4].ActorID != client.ID {
+		t.Fatalf("FindStatusHistory() = %+v, want 5 transitions ending with Hired by the client", history)
+	}
+}
+
+func TestJobApplicantServiceApplyJob(t *testing.T) {
+	client := NewActor(uuid.New(), entity.RoleClient)
+	applicant := NewActor(uuid.New(), entity.RoleApplicant)
+
+	job := &entity.Job{ID: uuid.New(), ClientID: client.ID}
+
+	newService := func(reapplyPolicy ReapplyPolicy, existing ...*entity.JobApplicants) (JobApplicantService, *fakeJobApplicantsRepository) {
+		repo := newFakeJobApplicantsRepository(existing...)
+		return NewJobApplicantService(repo, newFakeJobRepository(job), NewPolicy(), reapplyPolicy, newTestMetrics()), repo
+	}
+
+	newApplication := func(status string, updatedAt time.Time) *entity.JobApplicants {
+		return &entity.JobApplicants{ID: uuid.New(), JobID: job.ID, ApplicantID: applicant.ID, Status: status, Audit: entity.Audit{UpdatedAt: updatedAt}}
+	}
+
+	t.Run("first application is created", func(t *testing.T) {
+		s, repo := newService(NewReapplyPolicy(true, 0))
+
+		if _, err := s.ApplyJob(ctx, entity.NewJobApplicants(job.ID, applicant.ID, "")); err != nil {
+			t.Fatalf("ApplyJob() error = %v", err)
+		}
+		if len(repo.jobApplicants) != 1 {
+			t.Fatalf("applications = %d, want 1", len(repo.jobApplicants))
+		}
+	})
+
+	t.Run("active application is a duplicate", func(t *testing.T) {
+		s, _ := newService(NewReapplyPolicy(true, 0), newApplication(entity.ApplicationReviewed, time.Now()))
+
+		if _, err := s.ApplyJob(ctx, entity.NewJobApplicants(job.ID, applicant.ID, "")); !errors.Is(err, repository.ErrAlreadyApplied) {
+			t.Fatalf("ApplyJob() error = %v, want %v", err, repository.ErrAlreadyApplied)
+		}
+	})
+
+	t.Run("withdrawn application can be replaced", func(t *testing.T) {
+		withdrawn := newApplication(entity.ApplicationWithdrawn, time.Now().Add(-2*time.Hour))
+		s, repo := newService(NewReapplyPolicy(true, time.Hour), withdrawn)
+
+		jobApplicant, err := s.ApplyJob(ctx, entity.NewJobApplicants(job.ID, applicant.ID, ""))
+		if err != nil {
+			t.Fatalf("ApplyJob() error = %v", err)
+		}
+		if _, ok := repo.jobApplicants[withdrawn.ID]; ok {
+			t.Fatal("withdrawn application was not replaced")
+		}
+		if jobApplicant.Status != entity.ApplicationWaiting {
+			t.Fatalf("status = %s, want %s", jobApplicant.Status, entity.ApplicationWaiting)
+		}
+	})
+
+	t.Run("reapply respects the policy", func(t *testing.T) {
+		cases := map[string]ReapplyPolicy{
+			"disabled":        NewReapplyPolicy(false, 0),
+			"within cooldown": NewReapplyPolicy(true, time.Hour),
+		}
+
+		for name, reapplyPolicy := range cases {
+			s, _ := newService(reapplyPolicy, newApplication(entity.ApplicationWithdrawn, time.Now()))
+
+			if _, err := s.ApplyJob(ctx, entity.NewJobApplicants(job.ID, applicant.ID, "")); !errors.Is(err, ErrReapplyNotAllowed) {
+				t.Fatalf("%s: ApplyJob() error = %v, want %v", name, err, ErrReapplyNotAllowed)
+			}
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/internal/repository"
+	"github.com/DavidAfdal/workfinder/pkg/encrypt"
+	"github.com/google/uuid"
+)
+
+// KeyRotationProgress is reported after every batch, LastID is where a later run can resume from.
+type KeyRotationProgress struct {
+	LastID  uuid.UUID
+	Scanned int
+	Rotated int
+	// Skipped counts users updated by the app while they were being rotated, a later run picks them up if needed
+	Skipped int
+	// Failed counts users whose data couldn't be decrypted with any key of the keyring
+	Failed int
+}
+
+type KeyRotationService interface {
+	RotateUserPII(ctx context.Context, afterID uuid.UUID, batchSize int, progress func(*KeyRotationProgress) error) (*KeyRotationProgress, error)
+}
+
+type keyRotationService struct {
+	userPIIRepo repository.UserPIIRepository
+	keyring     encrypt.Keyring
+	blindIndex  encrypt.BlindIndex
+}
+
+func NewKeyRotationService(userPIIRepo repository.UserPIIRepository, keyring encrypt.Keyring, blindIndex encrypt.BlindIndex) KeyRotationService {
+	return &keyRotationService{userPIIRepo, keyring, blindIndex}
+}
+
+// RotateUserPII re-encrypts every user after afterID under the primary key, batchSize users at a time.
+// Plaintext written before encryption was enabled is encrypted and gets its blind index, values sealed before
+// they were bound to their row are bound. Running it again is safe, users already bound under the primary key
+// are left untouched.
+func (s *keyRotationService) RotateUserPII(ctx context.Context, afterID uuid.UUID, batchSize int, progress func(*KeyRotationProgress) error) (*KeyRotationProgress, error) {
+	result := &KeyRotationProgress{LastID: afterID}
+
+	for {
+		users, err := s.userPIIRepo.FindUserPII(ctx, result.LastID, batchSize)
+		if err != nil {
+			return result, err
+		}
+
+		if len(users) == 0 {
+			return result, nil
+		}
+
+		for i := range users {
+			if err := s.rotate(ctx, &users[i], result); err != nil {
+				return result, err
+			}
+		}
+
+		result.LastID = users[len(users)-1].ID
+
+		if progress != nil {
+			if err := progress(result); err != nil {
+				return result, err
+			}
+		}
+
+		if len(users) < batchSize {
+			return result, nil
+		}
+	}
+}
+
+func (s *keyRotationService) rotate(ctx context.Context, user *entity.UserPII, result *KeyRotationProgress) error {
+	result.Scanned++
+
+	if !s.keyring.NeedsReencrypt(user.Address) && !s.keyring.NeedsReencrypt(user.PhoneNumber) {
+		return nil
+	}
+
+	address, _, err := s.reencrypt(user.Address, encrypt.Binding("users", "address", user.ID))
+	if err != nil {
+		result.Failed++
+		return nil
+	}
+
+	phoneNumber, plainPhoneNumber, err := s.reencrypt(user.PhoneNumber, encrypt.Binding("users", "phone_number", user.ID))
+	if err != nil {
+		result.Failed++
+		return nil
+	}
+
+	updated := &entity.UserPII{
+		ID:               user.ID,
+		Address:          address,
+		PhoneNumber:      phoneNumber,
+		PhoneNumberIndex: s.blindIndex.Index(plainPhoneNumber),
+	}
+
+	ok, err := s.userPIIRepo.UpdateUserPII(ctx, user, updated)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		result.Rotated++
+	} else {
+		result.Skipped++
+	}
+
+	return nil
+}
+
+// reencrypt returns text sealed under the primary key and bound to binding, along with its plaintext.
+func (s *keyRotationService) reencrypt(text string, binding string) (string, string, error) {
+	plaintext := text
+
+	if encrypt.IsCiphertext(text) {
+		var err error
+		if plaintext, err = s.keyring.DecryptBound(text, binding); err != nil {
+			return "", "", err
+		}
+	}
+
+	if !s.keyring.NeedsReencrypt(text) {
+		return text, plaintext, nil
+	}
+
+	ciphertext, err := s.keyring.EncryptBound(plaintext, binding)
+
+	return ciphertext, plaintext, err
+}
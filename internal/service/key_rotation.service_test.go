@@ -0,0 +1,124 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/pkg/encrypt"
+	"github.com/google/uuid"
+)
+
+const (
+	oldTestKey = "0123456789abcdef0123456789abcdef"
+	newTestKey = "fedcba9876543210fedcba9876543210"
+)
+
+func newTestKeyring(t *testing.T, primary string) encrypt.Keyring {
+	t.Helper()
+
+	keyring, err := encrypt.NewKeyring(primary, map[string]string{"1": oldTestKey, "2": newTestKey})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	return keyring
+}
+
+// testUserID returns ids that sort in the order of n.
+func testUserID(n int) uuid.UUID {
+	return uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", n))
+}
+
+func TestKeyRotationServiceRotateUserPII(t *testing.T) {
+	oldKeyring := newTestKeyring(t, "1")
+	keyring := newTestKeyring(t, "2")
+	blindIndex, _ := encrypt.NewBlindIndex("test-blind-index-key")
+
+	sealed := func(text string, column string, id uuid.UUID) string {
+		ciphertext, _ := oldKeyring.EncryptBound(text, encrypt.Binding("users", column, id))
+		return ciphertext
+	}
+	// sealed under the primary key before values were bound to their row
+	unbound, _ := keyring.Encrypt("Jl. Gatot Subroto 4")
+	unknown, _ := encrypt.NewGCMTool("9", newTestKey)
+	lost, _ := unknown.Encrypt("Jl. Hilang 9")
+
+	users := []entity.UserPII{
+		{ID: testUserID(1), Address: sealed("Jl. Merdeka 1", "address", testUserID(1)), PhoneNumber: sealed("+6281234567890", "phone_number", testUserID(1)),
+			PhoneNumberIndex: blindIndex.Index("+6281234567890")},
+		// written before encryption was enabled
+		{ID: testUserID(2), Address: "Jl. Sudirman 2", PhoneNumber: "+6289876543210"},
+		{ID: testUserID(3)},
+		{ID: testUserID(4), Address: lost},
+		{ID: testUserID(5), Address: sealed("Jl. Thamrin 3", "address", testUserID(5))},
+		{ID: testUserID(6), Address: unbound},
+	}
+
+	repo := &fakeUserPIIRepository{users: users, changed: map[uuid.UUID]bool{testUserID(5): true}}
+	rotation := NewKeyRotationService(repo, keyring, blindIndex)
+
+	batches := 0
+	result, err := rotation.RotateUserPII(ctx, uuid.Nil, 2, func(*KeyRotationProgress) error {
+		batches++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RotateUserPII() error = %v", err)
+	}
+
+	if batches != 3 {
+		t.Fatalf("progress reported %d times, want once per batch of 2", batches)
+	}
+	if result.Scanned != 6 || result.Rotated != 3 || result.Skipped != 1 || result.Failed != 1 {
+		t.Fatalf("RotateUserPII() = %+v, want 6 scanned, 3 rotated, 1 skipped and 1 failed", result)
+	}
+	if result.LastID != testUserID(6) {
+		t.Fatalf("LastID = %s, want the last user", result.LastID)
+	}
+
+	for _, user := range repo.users {
+		if user.Address == lost || repo.changed[user.ID] {
+			continue
+		}
+
+		for _, text := range []string{user.Address, user.PhoneNumber} {
+			if keyring.NeedsReencrypt(text) {
+				t.Fatalf("user %s still holds %q, want it bound under key 2", user.ID, text)
+			}
+		}
+
+		if _, err := keyring.DecryptBound(user.Address, encrypt.Binding("users", "address", user.ID)); user.Address != "" && err != nil {
+			t.Fatalf("user %s address doesn't decrypt bound to its row: %v", user.ID, err)
+		}
+
+		phoneNumber, _ := keyring.DecryptBound(user.PhoneNumber, encrypt.Binding("users", "phone_number", user.ID))
+		if user.PhoneNumber != "" && user.PhoneNumberIndex != blindIndex.Index(phoneNumber) {
+			t.Fatalf("user %s phone number index wasn't recomputed", user.ID)
+		}
+	}
+
+	// a second run has nothing left to do but the user it can't decrypt
+	again, err := rotation.RotateUserPII(ctx, uuid.Nil, 2, nil)
+	if err != nil {
+		t.Fatalf("RotateUserPII() error = %v", err)
+	}
+	if again.Rotated != 0 || again.Skipped != 1 || again.Failed != 1 {
+		t.Fatalf("second RotateUserPII() = %+v, want only the changed and the unreadable user left", again)
+	}
+}
+
+func TestKeyRotationServiceResumesAfterID(t *testing.T) {
+	keyring := newTestKeyring(t, "2")
+	blindIndex, _ := encrypt.NewBlindIndex("test-blind-index-key")
+
+	repo := &fakeUserPIIRepository{users: []entity.UserPII{{ID: testUserID(1), Address: "a"}, {ID: testUserID(2), Address: "b"}}}
+
+	result, err := NewKeyRotationService(repo, keyring, blindIndex).RotateUserPII(ctx, testUserID(1), 10, nil)
+	if err != nil {
+		t.Fatalf("RotateUserPII() error = %v", err)
+	}
+
+	if result.Scanned != 1 || repo.users[0].Address != "a" || !encrypt.IsCiphertext(repo.users[1].Address) {
+		t.Fatalf("RotateUserPII() after the first user = %+v, want only the second user rotated", result)
+	}
+}
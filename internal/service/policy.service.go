@@ -0,0 +1,70 @@
+package service
+
+import (
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+	"github.com/google/uuid"
+)
+
+var ErrForbidden = apperror.Forbidden("forbidden", "you don't have access to this resource")
+
+// Actor is the authenticated user performing a request, taken from the JWT claims.
+type Actor struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func NewActor(id uuid.UUID, role string) Actor {
+	return Actor{ID: id, Role: role}
+}
+
+func (a Actor) IsAdmin() bool {
+	return a.Role == entity.RoleAdmin
+}
+
+// Policy decides whether an actor owns a resource. Admins pass every check.
+type Policy interface {
+	CanManageJob(actor Actor, job *entity.Job) error
+	CanReviewApplication(actor Actor, job *entity.Job) error
+	CanWithdrawApplication(actor Actor, jobApplicant *entity.JobApplicants) error
+	CanViewApplication(actor Actor, jobApplicant *entity.JobApplicants, job *entity.Job) error
+	CanManageProfile(actor Actor, userID uuid.UUID) error
+}
+
+type policy struct{}
+
+func NewPolicy() Policy {
+	return &policy{}
+}
+
+func (p *policy) CanManageJob(actor Actor, job *entity.Job) error {
+	if actor.IsAdmin() || job.ClientID == actor.ID {
+		return nil
+	}
+	return ErrForbidden
+}
+
+func (p *policy) CanReviewApplication(actor Actor, job *entity.Job) error {
+	return p.CanManageJob(actor, job)
+}
+
+func (p *policy) CanWithdrawApplication(actor Actor, jobApplicant *entity.JobApplicants) error {
+	if actor.IsAdmin() || jobApplicant.ApplicantID == actor.ID {
+		return nil
+	}
+	return ErrForbidden
+}
+
+func (p *policy) CanViewApplication(actor Actor, jobApplicant *entity.JobApplicants, job *entity.Job) error {
+	if p.CanWithdrawApplication(actor, jobApplicant) == nil {
+		return nil
+	}
+	return p.CanManageJob(actor, job)
+}
+
+func (p *policy) CanManageProfile(actor Actor, userID uuid.UUID) error {
+	if actor.IsAdmin() || userID == actor.ID {
+		return nil
+	}
+	return ErrForbidden
+}
@@ -1,14 +1,17 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"github.com/DavidAfdal/workfinder/internal/entity"
 	"github.com/DavidAfdal/workfinder/internal/repository"
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
 	"github.com/DavidAfdal/workfinder/pkg/token"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // TODO: Create User Service Struct and Interface
@@ -16,68 +19,177 @@ import (
 // TODO: Create User Service Implementation
 
 type UserService interface {
-	Login(email string, password string) (string, error)
-	CreateUser(user *entity.User) (*entity.User, error)
-	FindById(id uuid.UUID) (*entity.User, error)
-	FindAllUser() ([]entity.User, error)
-	UpdateUser(user *entity.User) (*entity.User, error)
-	DeleteUser(id uuid.UUID) (bool, error)
+	Login(ctx context.Context, email string, password string) (*token.TokenPair, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*token.TokenPair, error)
+	Logout(ctx context.Context, claims *token.JwtCustomClaims, refreshToken string) error
+	CreateUser(ctx context.Context, user *entity.User) (*entity.User, error)
+	FindById(ctx context.Context, id uuid.UUID) (*entity.User, error)
+	FindAllUser(ctx context.Context) ([]entity.User, error)
+	UpdateUser(ctx context.Context, actor Actor, user *entity.User) (*entity.User, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) (bool, error)
 }
 
+var (
+	ErrInvalidCredentials  = apperror.Unauthorized("invalid_credentials", "invalid email or password")
+	ErrInvalidRefreshToken = apperror.Unauthorized("invalid_refresh_token", "invalid refresh token")
+	ErrRefreshTokenReused  = apperror.Unauthorized("refresh_token_reused", "refresh token reused, please login again")
+	ErrRoleNotAllowed      = apperror.Validation("role_not_allowed", "role not allowed")
+)
+
 type userService struct {
   userRepo repository.UserRepository
+  refreshTokenRepo repository.RefreshTokenRepository
   tokenUseCase token.TokenUseCase
+  denylist token.Denylist
+  policy Policy
 }
 
-func NewUserService(userRepo repository.UserRepository, tokenUseCase token.TokenUseCase) UserService {
-	return &userService{userRepo, tokenUseCase}
+func NewUserService(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, tokenUseCase token.TokenUseCase, denylist token.Denylist, policy Policy) UserService {
+	return &userService{userRepo, refreshTokenRepo, tokenUseCase, denylist, policy}
 }
 
 
-func (s *userService) Login(email string, password string) (string, error) {
-	user, err := s.userRepo.FindByEmail(email)
+func (s *userService) Login(ctx context.Context, email string, password string) (*token.TokenPair, error) {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidCredentials
+	}
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 
 	if err != nil {
-		return "", err
+		return nil, ErrInvalidCredentials
+	}
+
+	refreshToken, err := s.tokenUseCase.GenerateRefreshToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	newRefreshToken := entity.NewRefreshToken(user.ID, uuid.New(), refreshToken.Hash, refreshToken.ExpiresAt)
+
+	if _, err := s.refreshTokenRepo.CreateRefreshToken(ctx, newRefreshToken); err != nil {
+		return nil, err
+	}
+
+	return s.generateTokenPair(user, refreshToken)
+}
+
+// RefreshToken rotates the refresh token. Presenting a token that was already rotated means it leaked,
+// so every token of its family is revoked and the user has to login again.
+func (s *userService) RefreshToken(ctx context.Context, refreshToken string) (*token.TokenPair, error) {
+	storedToken, err := s.refreshTokenRepo.FindRefreshTokenByHash(ctx, token.HashToken(refreshToken))
+
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if storedToken.IsRevoked() {
+		if err := s.refreshTokenRepo.RevokeRefreshTokenFamily(ctx, storedToken.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if storedToken.IsExpired() {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.FindById(ctx, storedToken.UserID)
+
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
 	}
 
-	expiredTime := time.Now().Local().Add(time.Minute * 5)
+	nextToken, err := s.tokenUseCase.GenerateRefreshToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	newRefreshToken := entity.NewRefreshToken(user.ID, storedToken.FamilyID, nextToken.Hash, nextToken.ExpiresAt)
+
+	if _, err := s.refreshTokenRepo.RotateRefreshToken(ctx, storedToken, newRefreshToken); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenAlreadyRotated) {
+			if err := s.refreshTokenRepo.RevokeRefreshTokenFamily(ctx, storedToken.FamilyID); err != nil {
+				return nil, err
+			}
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, err
+	}
+
+	return s.generateTokenPair(user, nextToken)
+}
+
+// Logout denylists the access token until it expires and, when given, revokes the refresh token family.
+func (s *userService) Logout(ctx context.Context, claims *token.JwtCustomClaims, refreshToken string) error {
+	if claims.ExpiresAt != nil {
+		if err := s.denylist.Add(ctx, claims.RegisteredClaims.ID, time.Until(claims.ExpiresAt.Time)); err != nil {
+			return err
+		}
+	}
 
+	if refreshToken == "" {
+		return nil
+	}
+
+	storedToken, err := s.refreshTokenRepo.FindRefreshTokenByHash(ctx, token.HashToken(refreshToken))
+
+	if err != nil || storedToken.UserID != claims.ID {
+		return ErrInvalidRefreshToken
+	}
+
+	return s.refreshTokenRepo.RevokeRefreshTokenFamily(ctx, storedToken.FamilyID)
+}
+
+func (s *userService) generateTokenPair(user *entity.User, refreshToken *token.RefreshToken) (*token.TokenPair, error) {
 	claims := token.JwtCustomClaims{
 		ID: user.ID,
 		Email: user.Email,
-		Address: user.Address,
-		PhoneNumber: user.PhoneNumber,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expiredTime),
-		},
+		Role: user.Role,
 	}
 
-	token, err := s.tokenUseCase.GenerateAccessToken(claims)
+	accessToken, err := s.tokenUseCase.GenerateAccessToken(claims)
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return token, nil
+	return &token.TokenPair{
+		AccessToken: accessToken,
+		RefreshToken: refreshToken.Token,
+	}, nil
 }
 
-func (s *userService) CreateUser(user *entity.User) (*entity.User, error) {
+func (s *userService) CreateUser(ctx context.Context, user *entity.User) (*entity.User, error) {
+	switch user.Role {
+	case "":
+		user.Role = entity.RoleApplicant
+	case entity.RoleClient, entity.RoleApplicant:
+	default:
+		return user, ErrRoleNotAllowed
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return user, err
 	}
 	user.Password = string(hashedPassword)
-	return s.userRepo.CreateUser(user)
+	return s.userRepo.CreateUser(ctx, user)
 }
 
-func (s *userService) UpdateUser(user *entity.User) (*entity.User, error) {
+func (s *userService) UpdateUser(ctx context.Context, actor Actor, user *entity.User) (*entity.User, error) {
+	if err := s.policy.CanManageProfile(actor, user.ID); err != nil {
+		return user, err
+	}
+
 	if user.Password != ""{
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 		if err != nil {
@@ -86,25 +198,25 @@ func (s *userService) UpdateUser(user *entity.User) (*entity.User, error) {
 		user.Password = string(hashedPassword)
 	}
 
-	return s.userRepo.UpdateUser(user)
+	return s.userRepo.UpdateUser(ctx, user)
 }
 
-func (s *userService) FindAllUser() ([]entity.User, error) {
-	return s.userRepo.FindAllUser()
+func (s *userService) FindAllUser(ctx context.Context) ([]entity.User, error) {
+	return s.userRepo.FindAllUser(ctx)
 }
 
-func (s *userService) FindById(id uuid.UUID) (*entity.User, error) {
-	return s.userRepo.FindById(id)
+func (s *userService) FindById(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	return s.userRepo.FindById(ctx, id)
 }
 
-func (s *userService) DeleteUser(id uuid.UUID) (bool, error) {
-	user, err := s.userRepo.FindById(id)
+func (s *userService) DeleteUser(ctx context.Context, id uuid.UUID) (bool, error) {
+	user, err := s.userRepo.FindById(ctx, id)
 
 	if err != nil {
 		return false, err
 	}
 
-	return s.userRepo.DeleteUser(user)
+	return s.userRepo.DeleteUser(ctx, user)
 }
 
 
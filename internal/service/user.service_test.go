@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DavidAfdal/workfinder/internal/entity"
+	"github.com/DavidAfdal/workfinder/pkg/token"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserServiceUpdateUserOwnership(t *testing.T) {
+	user := &entity.User{ID: uuid.New(), Role: entity.RoleApplicant}
+
+	tests := []struct {
+		name    string
+		actor   Actor
+		wantErr error
+	}{
+		{name: "own profile", actor: NewActor(user.ID, user.Role)},
+		{name: "admin", actor: NewActor(uuid.New(), entity.RoleAdmin)},
+		{name: "other user", actor: NewActor(uuid.New(), entity.RoleClient), wantErr: ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeUserRepository(user)
+			s := NewUserService(repo, newFakeRefreshTokenRepository(), nil, nil, NewPolicy())
+
+			_, err := s.UpdateUser(ctx, tt.actor, &entity.User{ID: user.ID, Address: "Jakarta"})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateUser() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && len(repo.updated) != 0 {
+				t.Fatalf("UpdateUser() updated the profile of another user")
+			}
+		})
+	}
+}
+
+func TestUserServiceCreateUserRejectsAdminRole(t *testing.T) {
+	s := NewUserService(newFakeUserRepository(), newFakeRefreshTokenRepository(), nil, nil, NewPolicy())
+
+	if _, err := s.CreateUser(ctx, &entity.User{Email: "admin@workfinder.id", Password: "secret", Role: entity.RoleAdmin}); err == nil {
+		t.Fatalf("CreateUser() with admin role error = nil, want error")
+	}
+
+	user, err := s.CreateUser(ctx, &entity.User{Email: "user@workfinder.id", Password: "secret"})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if user.Role != entity.RoleApplicant {
+		t.Fatalf("CreateUser() role = %q, want %q", user.Role, entity.RoleApplicant)
+	}
+}
+
+func TestUserServiceRefreshTokenRotation(t *testing.T) {
+	password, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	user := &entity.User{ID: uuid.New(), Email: "user@workfinder.id", Password: string(password), Role: entity.RoleApplicant}
+
+	refreshTokenRepo := newFakeRefreshTokenRepository()
+	denylist := newFakeDenylist()
+	s := NewUserService(newFakeUserRepository(user), refreshTokenRepo, token.NewTokenUseCase(token.NewHMACKeySet("secret"), time.Minute, time.Hour), denylist, NewPolicy())
+
+	loginTokens, err := s.Login(ctx, user.Email, "secret")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	rotatedTokens, err := s.RefreshToken(ctx, loginTokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if rotatedTokens.RefreshToken == loginTokens.RefreshToken {
+		t.Fatalf("RefreshToken() did not rotate the refresh token")
+	}
+
+	stored, _ := refreshTokenRepo.FindRefreshTokenByHash(ctx, token.HashToken(rotatedTokens.RefreshToken))
+	if stored.TokenHash == rotatedTokens.RefreshToken {
+		t.Fatalf("refresh token stored in plaintext")
+	}
+
+	if _, err := s.RefreshToken(ctx, loginTokens.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("RefreshToken() with a rotated token error = %v, want %v", err, ErrRefreshTokenReused)
+	}
+
+	if _, err := s.RefreshToken(ctx, rotatedTokens.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("RefreshToken() after reuse error = %v, want the whole family revoked", err)
+	}
+}
+
+func TestUserServiceLogoutDenylistsAccessToken(t *testing.T) {
+	denylist := newFakeDenylist()
+	s := NewUserService(newFakeUserRepository(), newFakeRefreshTokenRepository(), nil, denylist, NewPolicy())
+
+	claims := &token.JwtCustomClaims{ID: uuid.New()}
+	claims.RegisteredClaims.ID = uuid.NewString()
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+
+	if err := s.Logout(ctx, claims, ""); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if !denylist.Contains(ctx, claims.RegisteredClaims.ID) {
+		t.Fatalf("Logout() did not denylist the access token")
+	}
+}
@@ -0,0 +1,102 @@
+package apperror
+
+import (
+	"errors"
+)
+
+// Kind groups domain errors by what went wrong, pkg/server turns each kind into an HTTP status code.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+)
+
+// FieldError explains why a single request field was rejected.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error is a domain error. Code is a stable machine readable identifier and Message is safe to show to clients,
+// the wrapped Err is only meant for logs.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func New(kind Kind, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Validation(code string, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+func Unauthorized(code string, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code string, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func NotFound(code string, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code string, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Internal(code string, message string) *Error {
+	return New(KindInternal, code, message)
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any error with the same kind and code, so a sentinel still matches after WithMessage or Wrap.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// WithMessage returns a copy of the error with a more specific client message.
+func (e *Error) WithMessage(message string) *Error {
+	return &Error{Kind: e.Kind, Code: e.Code, Message: message, Fields: e.Fields, Err: e.Err}
+}
+
+// WithFields returns a copy of the error that reports which request fields were rejected.
+func (e *Error) WithFields(fields []FieldError) *Error {
+	return &Error{Kind: e.Kind, Code: e.Code, Message: e.Message, Fields: fields, Err: e.Err}
+}
+
+// Wrap returns a copy of the error that keeps err as its cause.
+func (e *Error) Wrap(err error) *Error {
+	return &Error{Kind: e.Kind, Code: e.Code, Message: e.Message, Fields: e.Fields, Err: err}
+}
+
+// As returns the first *Error in err's chain.
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
@@ -2,65 +2,103 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/DavidAfdal/workfinder/config"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
+var (
+	ErrCacheMiss        = errors.New("cache miss")
+	ErrCacheUnavailable = errors.New("cache unavailable")
+)
 
-
-func InitCache(config *config.RedisConfig) *redis.Client {
+// InitCache connects to Redis, every command is traced as a child of the span in its context. Only the
+// command name is recorded, keys and values may hold user data.
+func InitCache(config *config.RedisConfig) (*redis.Client, error) {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", config.Host, config.Port),
 		Password: config.Password,
 		DB:       0,
 	})
 
-	return rdb
-}
+	if err := redisotel.InstrumentTracing(rdb, redisotel.WithDBStatement(false)); err != nil {
+		return nil, err
+	}
 
-type Cacheable interface {
-	Get(key string) string
-	Set(key string, value interface{}, expire time.Duration) error
-	Delete(key string) error
+	return rdb, nil
 }
 
-type cacheable struct {
-	Redis *redis.Client
+// Cacheable stores values under a versioned namespace. Get returns ErrCacheMiss for missing or stale values and
+// ErrCacheUnavailable when the backend can't be reached, callers should read from the database in both cases.
+//
+// A tagged value is written with the Versions of its tags taken by Snapshot before it was read from the
+// database, and is only returned while none of those tags were invalidated since. Stamping the versions at
+// Set instead would let a value read before a write be cached after the write's Invalidate, as if current.
+type Cacheable interface {
+	Get(ctx context.Context, key string) (string, error)
+	// Snapshot returns the current version of every tag.
+	Snapshot(ctx context.Context, tags ...string) (Versions, error)
+	// Set caches value under versions, nil for an untagged value. If a tag was invalidated after versions
+	// were taken the value is already stale and isn't written.
+	Set(ctx context.Context, key string, value interface{}, expire time.Duration, versions Versions) error
+	Delete(ctx context.Context, key string) error
+	Invalidate(ctx context.Context, tags ...string) error
 }
 
+// Versions maps tags to the version they had when Snapshot was called.
+type Versions map[string]int64
 
-func NewCacheable(redis *redis.Client) Cacheable {
-	return &cacheable{Redis: redis}
+// Tags returns the tags of v.
+func (v Versions) Tags() []string {
+	tags := make([]string, 0, len(v))
+	for tag := range v {
+		tags = append(tags, tag)
+	}
+	return tags
 }
 
-
-func (c *cacheable) Set(key string, value interface{}, expire time.Duration) error {
-	if err := c.Redis.Set(context.Background(), key, value, expire).Err(); err != nil {
-		return err
+// current reports whether every tag of v is still at its version in latest.
+func (v Versions) current(latest Versions) bool {
+	for tag, version := range v {
+		if latest[tag] != version {
+			return false
+		}
 	}
-
-	return nil
+	return true
 }
 
-func (c *cacheable) Get(key string) string {
-	val, err := c.Redis.Get(context.Background(), key).Result()
-
-	if err == redis.Nil {
-		return ""
+// NewCache picks the implementation from CACHE_DRIVER: "redis", "memory" for local development and tests,
+// or "layered" for a local LRU in front of Redis. version is part of every key, see NewCacheable.
+func NewCache(config *config.CacheConfig, redis *redis.Client, version string) (Cacheable, error) {
+	switch config.Driver {
+	case "", "redis":
+		return NewCacheable(redis, config.Namespace, version), nil
+	case "memory":
+		return NewMemoryCacheable(config.Size), nil
+	case "layered":
+		return NewLayeredCacheable(NewMemoryCacheable(config.Size), NewCacheable(redis, config.Namespace, version), config.LocalExpire), nil
+	default:
+		return nil, fmt.Errorf("unknown cache driver %q", config.Driver)
 	}
-
-	return val
 }
 
-func (c *cacheable) Delete(key string) error {
-	_, err := c.Redis.Del(context.Background(), key).Result()
+// entry is what is actually stored, Tags holds the version of every tag when Value was cached.
+type entry struct {
+	Value string           `json:"v"`
+	Tags  map[string]int64 `json:"t,omitempty"`
+}
 
-	if err != nil {
-		return err
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
 	}
-
-	return nil
 }
@@ -0,0 +1,500 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DavidAfdal/workfinder/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var ctx = context.Background()
+
+func newTestRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	return client, mr
+}
+
+// snapshot returns the current versions of tags, as taken by a caller about to read from the database.
+func snapshot(t *testing.T, c Cacheable, tags ...string) Versions {
+	t.Helper()
+
+	versions, err := c.Snapshot(ctx, tags...)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	return versions
+}
+
+// get returns "" for any miss, so tests read like the old string based API.
+func get(t *testing.T, c Cacheable, key string) string {
+	t.Helper()
+
+	value, err := c.Get(ctx, key)
+	if err != nil && !errors.Is(err, ErrCacheMiss) && !errors.Is(err, ErrCacheUnavailable) {
+		t.Fatalf("Get(%q) error = %v", key, err)
+	}
+
+	return value
+}
+
+func TestCacheableInvalidateTags(t *testing.T) {
+	client, _ := newTestRedis(t)
+
+	drivers := map[string]Cacheable{
+		"redis":   NewCacheable(client, "workfinder", "v1"),
+		"memory":  NewMemoryCacheable(10),
+		"layered": NewLayeredCacheable(NewMemoryCacheable(10), NewCacheable(client, "workfinder", "layered"), time.Minute),
+	}
+
+	for name, c := range drivers {
+		t.Run(name, func(t *testing.T) {
+			if err := c.Set(ctx, "job:1", "backend engineer", time.Minute, snapshot(t, c, "jobs", "job:1")); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if err := c.Set(ctx, "job:2", "frontend engineer", time.Minute, snapshot(t, c, "jobs", "job:2")); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			if got := get(t, c, "job:1"); got != "backend engineer" {
+				t.Fatalf("Get() = %q, want cached value", got)
+			}
+
+			if err := c.Invalidate(ctx, "job:1"); err != nil {
+				t.Fatalf("Invalidate() error = %v", err)
+			}
+
+			if _, err := c.Get(ctx, "job:1"); !errors.Is(err, ErrCacheMiss) {
+				t.Fatalf("Get() after invalidation error = %v, want ErrCacheMiss", err)
+			}
+			if got := get(t, c, "job:2"); got != "frontend engineer" {
+				t.Fatalf("Get() of an entry with other tags = %q, want cached value", got)
+			}
+
+			if err := c.Invalidate(ctx, "jobs"); err != nil {
+				t.Fatalf("Invalidate() error = %v", err)
+			}
+
+			if got := get(t, c, "job:2"); got != "" {
+				t.Fatalf("Get() after shared tag invalidation = %q, want miss", got)
+			}
+
+			if err := c.Set(ctx, "job:1", "senior backend engineer", time.Minute, snapshot(t, c, "jobs", "job:1")); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if got := get(t, c, "job:1"); got != "senior backend engineer" {
+				t.Fatalf("Get() after refill = %q, want fresh value", got)
+			}
+		})
+	}
+}
+
+func TestCacheableDropsValueLoadedBeforeInvalidate(t *testing.T) {
+	client, _ := newTestRedis(t)
+
+	drivers := map[string]Cacheable{
+		"redis":   NewCacheable(client, "workfinder", "v1"),
+		"memory":  NewMemoryCacheable(10),
+		"layered": NewLayeredCacheable(NewMemoryCacheable(10), NewCacheable(client, "workfinder", "layered"), time.Minute),
+	}
+
+	for name, c := range drivers {
+		t.Run(name, func(t *testing.T) {
+			// a reader snapshots and loads the old row
+			stale := snapshot(t, c, "jobs", "job:1")
+
+			// a writer commits and invalidates before the reader caches what it loaded
+			if err := c.Invalidate(ctx, "job:1"); err != nil {
+				t.Fatalf("Invalidate() error = %v", err)
+			}
+			fresh := snapshot(t, c, "jobs", "job:1")
+			if err := c.Set(ctx, "job:1", "senior backend engineer", time.Minute, fresh); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			if err := c.Set(ctx, "job:1", "backend engineer", time.Minute, stale); err != nil {
+				t.Fatalf("Set() of the stale value error = %v", err)
+			}
+
+			if got := get(t, c, "job:1"); got != "senior backend engineer" {
+				t.Fatalf("Get() = %q, want the value loaded after the write", got)
+			}
+
+			// without a newer value the stale one still isn't served
+			if err := c.Delete(ctx, "job:1"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if err := c.Set(ctx, "job:1", "backend engineer", time.Minute, stale); err != nil {
+				t.Fatalf("Set() of the stale value error = %v", err)
+			}
+			if got := get(t, c, "job:1"); got != "" {
+				t.Fatalf("Get() = %q, want miss for a value loaded before the write", got)
+			}
+		})
+	}
+}
+
+// blockingPipeline holds every pipeline, the invalidation flush, until release is closed.
+type blockingPipeline struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingPipeline) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *blockingPipeline) ProcessHook(next redis.ProcessHook) redis.ProcessHook { return next }
+
+func (h *blockingPipeline) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		close(h.started)
+		<-h.release
+		return next(ctx, cmds)
+	}
+}
+
+func TestCacheableFlushDoesNotBlockOtherCalls(t *testing.T) {
+	client, _ := newTestRedis(t)
+	c := NewCacheable(client, "workfinder", "v1")
+
+	if err := c.Set(ctx, "job:1", "backend engineer", time.Minute, snapshot(t, c, "job:1")); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set(ctx, "job:2", "frontend engineer", time.Minute, snapshot(t, c, "job:2")); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	hook := &blockingPipeline{started: make(chan struct{}), release: make(chan struct{})}
+	client.AddHook(hook)
+
+	invalidated := make(chan struct{})
+	go func() {
+		defer close(invalidated)
+		c.Invalidate(ctx, "job:1")
+	}()
+	<-hook.started
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if got := get(t, c, "job:2"); got != "frontend engineer" {
+			t.Errorf("Get() during the flush = %q, want the cached value", got)
+		}
+		// its invalidation is in flight, the old version in Redis must not be trusted
+		if got := get(t, c, "job:1"); got != "" {
+			t.Errorf("Get() of an entry being invalidated = %q, want miss", got)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Get() waited for the invalidation flush")
+	}
+
+	close(hook.release)
+	<-invalidated
+
+	if got := get(t, c, "job:1"); got != "" {
+		t.Fatalf("Get() after the flush = %q, want miss", got)
+	}
+}
+
+func TestCacheableVersionedNamespace(t *testing.T) {
+	client, _ := newTestRedis(t)
+
+	v1 := NewCacheable(client, "workfinder", "v1")
+	v2 := NewCacheable(client, "workfinder", "v2")
+
+	if err := v1.Set(ctx, "users", "[]", time.Minute, nil); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if got := get(t, v2, "users"); got != "" {
+		t.Fatalf("Get() from a newer version = %q, want miss", got)
+	}
+	if got := get(t, v1, "users"); got != "[]" {
+		t.Fatalf("Get() = %q, want cached value", got)
+	}
+}
+
+func TestCacheableExpiresTagVersions(t *testing.T) {
+	client, mr := newTestRedis(t)
+	c := NewCacheable(client, "workfinder", "v1")
+
+	if err := c.Invalidate(ctx, "job:1"); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	if ttl := mr.TTL("workfinder:v1:tag:job:1"); ttl != tagExpire {
+		t.Fatalf("tag TTL after Invalidate() = %v, want %v", ttl, tagExpire)
+	}
+
+	mr.FastForward(time.Hour)
+
+	if err := c.Set(ctx, "job:1", "backend engineer", 0, snapshot(t, c, "job:1")); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if ttl := mr.TTL("workfinder:v1:tag:job:1"); ttl != tagExpire {
+		t.Fatalf("tag TTL after Set() = %v, want %v, refreshed by the entry", ttl, tagExpire)
+	}
+	if ttl := mr.TTL("workfinder:v1:job:1"); ttl != tagExpire {
+		t.Fatalf("entry TTL = %v, want %v, capped to the tag versions", ttl, tagExpire)
+	}
+
+	mr.FastForward(tagExpire)
+
+	if mr.Exists("workfinder:v1:tag:job:1") || mr.Exists("workfinder:v1:job:1") {
+		t.Fatalf("tag version or entry kept past tagExpire")
+	}
+}
+
+func TestCacheableRedisUnavailable(t *testing.T) {
+	client, mr := newTestRedis(t)
+	c := NewCacheable(client, "workfinder", "v1")
+
+	if err := c.Set(ctx, "job:1", "backend engineer", time.Minute, snapshot(t, c, "job:1")); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	mr.Close()
+
+	if _, err := c.Get(ctx, "job:1"); !errors.Is(err, ErrCacheUnavailable) {
+		t.Fatalf("Get() while redis is down error = %v, want ErrCacheUnavailable", err)
+	}
+	if err := c.Set(ctx, "job:2", "frontend engineer", time.Minute, nil); !errors.Is(err, ErrCacheUnavailable) {
+		t.Fatalf("Set() while redis is down error = %v, want ErrCacheUnavailable", err)
+	}
+
+	// the job changed while redis was down, the write must not fail because of the cache
+	if err := c.Invalidate(ctx, "job:1"); err != nil {
+		t.Fatalf("Invalidate() while redis is down error = %v", err)
+	}
+
+	if err := mr.Restart(); err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+
+	// skip the back-off instead of sleeping through it
+	c.(*cacheable).downUntil = time.Time{}
+
+	if _, err := c.Get(ctx, "job:1"); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Get() after recovery error = %v, want the stale entry to be a miss", err)
+	}
+}
+
+func TestCacheableCancelledRequestKeepsRedisUp(t *testing.T) {
+	client, _ := newTestRedis(t)
+	c := NewCacheable(client, "workfinder", "v1")
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if _, err := c.Get(cancelled, "job:1"); err == nil {
+		t.Fatal("Get() with a cancelled context succeeded")
+	}
+
+	// the client went away, that says nothing about redis
+	if !c.(*cacheable).downUntil.IsZero() {
+		t.Fatal("a cancelled request marked redis down")
+	}
+	if err := c.Set(ctx, "job:1", "backend engineer", time.Minute, nil); err != nil {
+		t.Fatalf("Set() after a cancelled request error = %v", err)
+	}
+}
+
+func TestInitCacheTracesCommands(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	mr := miniredis.RunT(t)
+	client, err := InitCache(&config.RedisConfig{Host: mr.Host(), Port: mr.Port()})
+	if err != nil {
+		t.Fatalf("InitCache() error = %v", err)
+	}
+	defer client.Close()
+
+	c := NewCacheable(client, "workfinder", "v1")
+
+	traced, request := provider.Tracer("test").Start(ctx, "GET /jobs/:id")
+	if err := c.Set(traced, "job:1", "backend engineer", time.Minute, nil); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := c.Get(traced, "job:1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	request.End()
+
+	var commands []string
+	for _, span := range recorder.Ended() {
+		// connections are dialed by the pool, outside of any request
+		if span.Name() == "GET /jobs/:id" || span.Name() == "redis.dial" {
+			continue
+		}
+		if span.Parent().SpanID() != request.SpanContext().SpanID() {
+			t.Fatalf("span %q isn't a child of the request", span.Name())
+		}
+		for _, attr := range span.Attributes() {
+			if attr.Key == "db.statement" {
+				t.Fatalf("span %q records the statement %q, keys and values must stay out of traces", span.Name(), attr.Value.AsString())
+			}
+		}
+		commands = append(commands, span.Name())
+	}
+
+	if len(commands) != 2 || commands[0] != "set" || commands[1] != "get" {
+		t.Fatalf("traced commands = %v, want set and get", commands)
+	}
+}
+
+func TestMemoryCacheableEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewMemoryCacheable(2)
+
+	c.Set(ctx, "a", "1", 0, nil)
+	c.Set(ctx, "b", "2", 0, nil)
+
+	// reading a makes b the least recently used entry
+	if got := get(t, c, "a"); got != "1" {
+		t.Fatalf("Get(a) = %q, want 1", got)
+	}
+
+	c.Set(ctx, "c", "3", 0, nil)
+
+	if got := get(t, c, "b"); got != "" {
+		t.Fatalf("Get(b) = %q, want it evicted", got)
+	}
+	if got := get(t, c, "a"); got != "1" {
+		t.Fatalf("Get(a) = %q, want 1", got)
+	}
+	if got := get(t, c, "c"); got != "3" {
+		t.Fatalf("Get(c) = %q, want 3", got)
+	}
+}
+
+func TestMemoryCacheableExpire(t *testing.T) {
+	c := NewMemoryCacheable(10)
+
+	now := time.Now()
+	c.(*memoryCacheable).now = func() time.Time { return now }
+
+	c.Set(ctx, "job:1", "backend engineer", time.Minute, nil)
+
+	now = now.Add(59 * time.Second)
+	if got := get(t, c, "job:1"); got != "backend engineer" {
+		t.Fatalf("Get() before expiry = %q, want cached value", got)
+	}
+
+	now = now.Add(time.Second)
+	if _, err := c.Get(ctx, "job:1"); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Get() after expiry error = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestMemoryCacheableBoundsTagVersions(t *testing.T) {
+	c := NewMemoryCacheable(2)
+	memory := c.(*memoryCacheable)
+
+	for i := 0; i < 100; i++ {
+		tag := fmt.Sprintf("job:%d", i)
+		c.Set(ctx, tag, "job", 0, snapshot(t, c, tag, "jobs"))
+		c.Invalidate(ctx, fmt.Sprintf("jobs:client:%d", i))
+	}
+
+	// the two stored jobs carry 3 tags, the evicted ones and the tags nothing carries are gone
+	if len(memory.tags) != 3 || len(memory.refs) != 3 {
+		t.Fatalf("memory keeps %d tag versions, want only the 3 of stored values", len(memory.tags))
+	}
+
+	// a dropped tag doesn't go back to an older version, a value loaded before its invalidation is refused
+	versions := snapshot(t, c, "job:1000")
+	c.Invalidate(ctx, "job:1000")
+	c.Set(ctx, "job:1000", "stale", 0, versions)
+	if got := get(t, c, "job:1000"); got != "" {
+		t.Fatalf("Get() = %q, want the value loaded before the invalidation dropped", got)
+	}
+
+	if got := get(t, c, "job:99"); got != "job" {
+		t.Fatalf("Get() = %q, want the stored job untouched", got)
+	}
+}
+
+func TestLayeredCacheableInvalidateKeepsTags(t *testing.T) {
+	client, _ := newTestRedis(t)
+	c := NewLayeredCacheable(NewMemoryCacheable(10), NewCacheable(client, "workfinder", "v1"), time.Minute)
+
+	tags := make([]string, 1, 2)
+	tags[0] = "jobs"
+	spare := tags[:2]
+
+	if err := c.Invalidate(ctx, tags...); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	if spare[1] != "" {
+		t.Fatalf("Invalidate() wrote %q into the array of the caller", spare[1])
+	}
+}
+
+func TestLayeredCacheableServesLocalCopy(t *testing.T) {
+	client, mr := newTestRedis(t)
+	remote := NewCacheable(client, "workfinder", "v1")
+	c := NewLayeredCacheable(NewMemoryCacheable(10), remote, time.Minute)
+
+	if err := remote.Set(ctx, "jobs", "[]", time.Minute, snapshot(t, remote, "jobs")); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if got := get(t, c, "jobs"); got != "[]" {
+		t.Fatalf("Get() = %q, want the remote value", got)
+	}
+
+	mr.Close()
+
+	if got := get(t, c, "jobs"); got != "[]" {
+		t.Fatalf("Get() while redis is down = %q, want the local copy", got)
+	}
+
+	// a local invalidation drops copies of remote values even though their tags are unknown here
+	if err := c.Invalidate(ctx, "jobs"); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	if got := get(t, c, "jobs"); got != "" {
+		t.Fatalf("Get() after invalidation = %q, want miss", got)
+	}
+}
+
+func TestMeteredCacheableCountsResults(t *testing.T) {
+	client, mr := newTestRedis(t)
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "cache_requests_total"}, []string{"cache", "result"})
+	c := NewMeteredCacheable(NewCacheable(client, "workfinder", "v1"), "app", requests)
+
+	c.Set(ctx, "job:1", "backend engineer", time.Minute, nil)
+	c.Get(ctx, "job:1")
+	c.Get(ctx, "job:2")
+
+	mr.Close()
+	c.Get(ctx, "job:1")
+
+	for result, want := range map[string]float64{"hit": 1, "miss": 1, "error": 1} {
+		if got := testutil.ToFloat64(requests.WithLabelValues("app", result)); got != want {
+			t.Fatalf("%s count = %v, want %v", result, got, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// remoteTag marks local copies of values read from the remote cache. Their real tags are unknown locally,
+// so every local invalidation drops them too.
+const remoteTag = "cache:remote"
+
+// localPrefix marks the versions of the local cache in a layered snapshot.
+const localPrefix = "local:"
+
+type layeredCacheable struct {
+	local       Cacheable
+	remote      Cacheable
+	localExpire time.Duration
+}
+
+// NewLayeredCacheable reads through a local L1 in front of a shared remote L2. Local copies live for at most
+// localExpire, which bounds how long an invalidation made by another instance takes to be seen here.
+func NewLayeredCacheable(local Cacheable, remote Cacheable, localExpire time.Duration) Cacheable {
+	return &layeredCacheable{local: local, remote: remote, localExpire: localExpire}
+}
+
+func (c *layeredCacheable) Get(ctx context.Context, key string) (string, error) {
+	if value, err := c.local.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	// taken first, a local invalidation during the remote read drops the copy
+	versions, _ := c.local.Snapshot(ctx, remoteTag)
+
+	value, err := c.remote.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	c.local.Set(ctx, key, value, c.localExpire, versions)
+
+	return value, nil
+}
+
+// Snapshot takes the versions of both layers. Nothing new is cached while the remote cache is unavailable,
+// a value without remote versions could be served after a write.
+func (c *layeredCacheable) Snapshot(ctx context.Context, tags ...string) (Versions, error) {
+	remote, err := c.remote.Snapshot(ctx, tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := c.local.Snapshot(ctx, tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(remote) == 0 && len(local) == 0 {
+		return nil, nil
+	}
+
+	versions := make(Versions, len(remote)+len(local))
+	for tag, version := range remote {
+		versions[tag] = version
+	}
+	for tag, version := range local {
+		versions[localPrefix+tag] = version
+	}
+
+	return versions, nil
+}
+
+func (c *layeredCacheable) Set(ctx context.Context, key string, value interface{}, expire time.Duration, versions Versions) error {
+	localExpire := c.localExpire
+	if expire > 0 && expire < localExpire {
+		localExpire = expire
+	}
+
+	local, remote := splitVersions(versions)
+
+	c.local.Set(ctx, key, value, localExpire, local)
+
+	return c.remote.Set(ctx, key, value, expire, remote)
+}
+
+// splitVersions undoes Snapshot, separating the versions of the local cache from those of the remote one.
+func splitVersions(versions Versions) (Versions, Versions) {
+	if len(versions) == 0 {
+		return nil, nil
+	}
+
+	local, remote := make(Versions), make(Versions)
+	for tag, version := range versions {
+		if strings.HasPrefix(tag, localPrefix) {
+			local[strings.TrimPrefix(tag, localPrefix)] = version
+		} else {
+			remote[tag] = version
+		}
+	}
+
+	return local, remote
+}
+
+func (c *layeredCacheable) Delete(ctx context.Context, key string) error {
+	c.local.Delete(ctx, key)
+
+	return c.remote.Delete(ctx, key)
+}
+
+func (c *layeredCacheable) Invalidate(ctx context.Context, tags ...string) error {
+	// copied, appending to tags could write into the array of the caller
+	localTags := append(append(make([]string, 0, len(tags)+1), tags...), remoteTag)
+	c.local.Invalidate(ctx, localTags...)
+
+	return c.remote.Invalidate(ctx, tags...)
+}
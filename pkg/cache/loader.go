@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Loader reads values through the cache. Concurrent misses on the same key share a single load, so an
+// expiring hot key costs the database one query instead of one per request.
+type Loader interface {
+	// Load fills dest with the cached value of key, or with the JSON encoding of what load returns. The
+	// result is cached for expire, tagged with tags.
+	Load(ctx context.Context, key string, dest interface{}, expire time.Duration, load func(ctx context.Context) (interface{}, error), tags ...string) error
+}
+
+// loaded is what the loader stores, FreshUntil tells a fresh value from one only kept for staleWindow.
+type loaded struct {
+	Data       json.RawMessage `json:"d"`
+	FreshUntil int64           `json:"f"`
+}
+
+type loader struct {
+	cache       Cacheable
+	group       singleflight.Group
+	staleWindow time.Duration
+	jitter      float64
+	now         func() time.Time
+}
+
+// NewLoader serves a value for up to staleWindow past its expiry while a single goroutine refreshes it,
+// a zero staleWindow always loads on expiry. Every expiry is spread by up to jitter (0.1 is ±10%) so keys
+// cached together don't all expire together.
+//
+// Invalidated values are never served stale: the tag versions are taken before every load and checked by the
+// underlying cache, so neither an expired value nor one loaded while its tags were invalidated is returned.
+// A background refresh outlives the request that triggered it, it keeps the values of its context but not
+// its cancellation.
+func NewLoader(cache Cacheable, staleWindow time.Duration, jitter float64) Loader {
+	return &loader{cache: cache, staleWindow: staleWindow, jitter: jitter, now: time.Now}
+}
+
+func (l *loader) Load(ctx context.Context, key string, dest interface{}, expire time.Duration, load func(ctx context.Context) (interface{}, error), tags ...string) error {
+	if data, err := l.cache.Get(ctx, key); err == nil {
+		var cached loaded
+		if err := json.Unmarshal([]byte(data), &cached); err == nil {
+			if l.now().UnixNano() >= cached.FreshUntil {
+				background := context.WithoutCancel(ctx)
+				l.group.DoChan(key, func() (interface{}, error) {
+					return l.refresh(background, key, expire, load, tags)
+				})
+			}
+
+			return json.Unmarshal(cached.Data, dest)
+		}
+	}
+
+	data, err, shared := l.group.Do(key, func() (interface{}, error) {
+		return l.refresh(ctx, key, expire, load, tags)
+	})
+
+	// the load ran under the context of whichever caller started it, if that caller went away this one
+	// still has to be answered
+	if shared && isContextError(err) && ctx.Err() == nil {
+		data, err = l.refresh(ctx, key, expire, load, tags)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data.([]byte), dest)
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// refresh loads the value and caches it, a failing cache doesn't fail the load. The tag versions are taken
+// before the load, if a tag is invalidated while it runs the cache drops the write.
+func (l *loader) refresh(ctx context.Context, key string, expire time.Duration, load func(ctx context.Context) (interface{}, error), tags []string) ([]byte, error) {
+	versions, snapshotErr := l.cache.Snapshot(ctx, tags...)
+
+	value, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	expire = l.jittered(expire)
+
+	entry, err := json.Marshal(loaded{Data: data, FreshUntil: l.now().Add(expire).UnixNano()})
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshotErr == nil {
+		l.cache.Set(ctx, key, entry, expire+l.staleWindow, versions)
+	}
+
+	return data, nil
+}
+
+func (l *loader) jittered(expire time.Duration) time.Duration {
+	if l.jitter <= 0 || expire <= 0 {
+		return expire
+	}
+
+	spread := float64(expire) * l.jitter
+
+	return expire + time.Duration((rand.Float64()*2-1)*spread)
+}
@@ -0,0 +1,259 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoaderCoalescesConcurrentMisses(t *testing.T) {
+	l := NewLoader(NewMemoryCacheable(10), 0, 0)
+
+	var calls int32
+	release := make(chan struct{})
+
+	load := func(context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return []string{"backend engineer"}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]string, 10)
+
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := l.Load(ctx, "jobs", &results[i], time.Minute, load); err != nil {
+				t.Errorf("Load() error = %v", err)
+			}
+		}(i)
+	}
+
+	// give every goroutine the chance to join the in-flight load
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("load called %d times, want 1", calls)
+	}
+	for i, jobs := range results {
+		if len(jobs) != 1 || jobs[0] != "backend engineer" {
+			t.Fatalf("result %d = %v", i, jobs)
+		}
+	}
+}
+
+func TestLoaderCancelledCallerDoesNotFailOthers(t *testing.T) {
+	l := NewLoader(NewMemoryCacheable(10), 0, 0)
+
+	var calls int32
+	started := make(chan struct{})
+
+	load := func(ctx context.Context) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return []string{"backend engineer"}, nil
+	}
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	leaderErr := make(chan error, 1)
+	go func() {
+		var jobs []string
+		leaderErr <- l.Load(leaderCtx, "jobs", &jobs, time.Minute, load)
+	}()
+	<-started
+
+	followerErr := make(chan error, 1)
+	var jobs []string
+	go func() {
+		followerErr <- l.Load(ctx, "jobs", &jobs, time.Minute, load)
+	}()
+
+	// let the follower join the in-flight load before its caller goes away
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Load() of the cancelled caller error = %v, want context.Canceled", err)
+	}
+	if err := <-followerErr; err != nil {
+		t.Fatalf("Load() of the live caller error = %v", err)
+	}
+	if len(jobs) != 1 || jobs[0] != "backend engineer" {
+		t.Fatalf("Load() of the live caller = %v", jobs)
+	}
+}
+
+func TestLoaderDropsValueInvalidatedDuringLoad(t *testing.T) {
+	client, _ := newTestRedis(t)
+
+	drivers := map[string]Cacheable{
+		"redis":  NewCacheable(client, "workfinder", "v1"),
+		"memory": NewMemoryCacheable(10),
+	}
+
+	for name, c := range drivers {
+		t.Run(name, func(t *testing.T) {
+			l := NewLoader(c, time.Minute, 0)
+
+			row := "backend engineer"
+			load := func(context.Context) (interface{}, error) {
+				loaded := row
+				if row == "backend engineer" {
+					// a writer commits and invalidates after the old row was read
+					row = "senior backend engineer"
+					c.Invalidate(ctx, "job:1")
+				}
+				return loaded, nil
+			}
+
+			var got string
+			if err := l.Load(ctx, "job:1", &got, time.Minute, load, "job:1"); err != nil || got != "backend engineer" {
+				t.Fatalf("Load() = %q, %v", got, err)
+			}
+
+			if err := l.Load(ctx, "job:1", &got, time.Minute, load, "job:1"); err != nil || got != "senior backend engineer" {
+				t.Fatalf("Load() after the write = %q, %v, want the value loaded after it", got, err)
+			}
+		})
+	}
+}
+
+func TestLoaderRefreshDropsValueInvalidatedDuringLoad(t *testing.T) {
+	c := NewMemoryCacheable(10)
+	l := NewLoader(c, time.Minute, 0).(*loader)
+
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	c.(*memoryCacheable).now = func() time.Time { return now }
+
+	var got string
+	if err := l.Load(ctx, "job:1", &got, time.Minute, func(context.Context) (interface{}, error) {
+		return "v1", nil
+	}, "job:1"); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	now = now.Add(90 * time.Second)
+
+	refreshed := make(chan struct{})
+	if err := l.Load(ctx, "job:1", &got, time.Minute, func(context.Context) (interface{}, error) {
+		defer close(refreshed)
+		// the write lands while the background refresh still holds the row it read
+		c.Invalidate(ctx, "job:1")
+		return "v2", nil
+	}, "job:1"); err != nil || got != "v1" {
+		t.Fatalf("Load() inside the stale window = %q, %v, want the stale value", got, err)
+	}
+	<-refreshed
+
+	// let the refresh finish storing, or not storing, its value
+	time.Sleep(10 * time.Millisecond)
+
+	if err := l.Load(ctx, "job:1", &got, time.Minute, func(context.Context) (interface{}, error) {
+		return "v3", nil
+	}, "job:1"); err != nil || got != "v3" {
+		t.Fatalf("Load() after the write = %q, %v, want a fresh load instead of the refreshed value", got, err)
+	}
+}
+
+func TestLoaderServesStaleWhileRevalidating(t *testing.T) {
+	c := NewMemoryCacheable(10)
+	l := NewLoader(c, time.Minute, 0).(*loader)
+
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	c.(*memoryCacheable).now = func() time.Time { return now }
+
+	refreshed := make(chan struct{})
+	value := "v1"
+	load := func(context.Context) (interface{}, error) {
+		if value == "v2" {
+			defer close(refreshed)
+		}
+		return value, nil
+	}
+
+	var got string
+	if err := l.Load(ctx, "job:1", &got, time.Minute, load); err != nil || got != "v1" {
+		t.Fatalf("Load() = %q, %v", got, err)
+	}
+
+	value = "v2"
+	now = now.Add(90 * time.Second)
+
+	if err := l.Load(ctx, "job:1", &got, time.Minute, load); err != nil || got != "v1" {
+		t.Fatalf("Load() inside the stale window = %q, %v, want the stale value", got, err)
+	}
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("stale value was not refreshed in the background")
+	}
+
+	// wait for the refreshed value to be stored
+	for i := 0; i < 100 && got != "v2"; i++ {
+		time.Sleep(time.Millisecond)
+		if err := l.Load(ctx, "job:1", &got, time.Minute, load); err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+	}
+	if got != "v2" {
+		t.Fatalf("Load() after refresh = %q, want v2", got)
+	}
+}
+
+func TestLoaderNeverServesInvalidatedValue(t *testing.T) {
+	c := NewMemoryCacheable(10)
+	l := NewLoader(c, time.Hour, 0)
+
+	value := "v1"
+	load := func(context.Context) (interface{}, error) { return value, nil }
+
+	var got string
+	if err := l.Load(ctx, "job:1", &got, time.Minute, load, "job:1"); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	value = "v2"
+	c.Invalidate(ctx, "job:1")
+
+	if err := l.Load(ctx, "job:1", &got, time.Minute, load, "job:1"); err != nil || got != "v2" {
+		t.Fatalf("Load() after invalidation = %q, %v, want v2", got, err)
+	}
+}
+
+func TestLoaderDoesNotCacheErrors(t *testing.T) {
+	l := NewLoader(NewMemoryCacheable(10), 0, 0)
+
+	errNotFound := errors.New("not found")
+
+	var got string
+	if err := l.Load(ctx, "job:1", &got, time.Minute, func(context.Context) (interface{}, error) { return nil, errNotFound }); !errors.Is(err, errNotFound) {
+		t.Fatalf("Load() error = %v, want %v", err, errNotFound)
+	}
+
+	if err := l.Load(ctx, "job:1", &got, time.Minute, func(context.Context) (interface{}, error) { return "found", nil }); err != nil || got != "found" {
+		t.Fatalf("Load() = %q, %v, want found", got, err)
+	}
+}
+
+func TestLoaderJitter(t *testing.T) {
+	l := NewLoader(NewMemoryCacheable(10), 0, 0.1).(*loader)
+
+	for i := 0; i < 100; i++ {
+		if got := l.jittered(time.Minute); got < 54*time.Second || got > 66*time.Second {
+			t.Fatalf("jittered(1m) = %v, want within ±10%%", got)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"context"
+	"container/list"
+	"sync"
+	"time"
+)
+
+type memoryItem struct {
+	key       string
+	value     string
+	tags      map[string]int64
+	expiresAt time.Time
+}
+
+// memoryCacheable is an in-process LRU, it never fails so it also works without Redis.
+type memoryCacheable struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	// order keeps the most recently used item in front
+	order *list.List
+	// tags holds the version of every tag a stored item carries, refs counts those items. A tag no item
+	// carries anymore is dropped and reads as dropped, the highest version dropped so far, so the version of a
+	// tag never goes back and a value loaded before an invalidation is still refused. Versions come from clock,
+	// they are unique across tags.
+	tags    map[string]int64
+	refs    map[string]int
+	clock   int64
+	dropped int64
+	now     func() time.Time
+}
+
+// NewMemoryCacheable keeps at most capacity values and evicts the least recently used one first.
+func NewMemoryCacheable(capacity int) Cacheable {
+	if capacity <= 0 {
+		capacity = 1
+	}
+
+	return &memoryCacheable{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		tags:     make(map[string]int64),
+		refs:     make(map[string]int),
+		now:      time.Now,
+	}
+}
+
+func (c *memoryCacheable) Get(ctx context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return "", ErrCacheMiss
+	}
+
+	item := element.Value.(*memoryItem)
+
+	if !item.expiresAt.IsZero() && !c.now().Before(item.expiresAt) {
+		c.remove(element)
+		return "", ErrCacheMiss
+	}
+
+	for tag, version := range item.tags {
+		if c.version(tag) != version {
+			c.remove(element)
+			return "", ErrCacheMiss
+		}
+	}
+
+	c.order.MoveToFront(element)
+
+	return item.value, nil
+}
+
+func (c *memoryCacheable) Snapshot(ctx context.Context, tags ...string) (Versions, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.snapshot(tags), nil
+}
+
+func (c *memoryCacheable) Set(ctx context.Context, key string, value interface{}, expire time.Duration, versions Versions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// invalidated while it was loaded, Get would never return it
+	if !versions.current(c.snapshot(versions.Tags())) {
+		return nil
+	}
+
+	item := &memoryItem{key: key, value: toString(value), tags: versions}
+
+	if expire > 0 {
+		item.expiresAt = c.now().Add(expire)
+	}
+
+	for tag, version := range versions {
+		c.tags[tag] = version
+		c.refs[tag]++
+	}
+
+	if element, ok := c.items[key]; ok {
+		c.release(element.Value.(*memoryItem))
+		element.Value = item
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.items[key] = c.order.PushFront(item)
+
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+
+	return nil
+}
+
+func (c *memoryCacheable) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.remove(element)
+	}
+
+	return nil
+}
+
+func (c *memoryCacheable) Invalidate(ctx context.Context, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		c.clock++
+		c.tags[tag] = c.clock
+
+		// nothing stored carries it, only a load in flight could and dropped refuses that one too
+		if c.refs[tag] == 0 {
+			c.drop(tag)
+		}
+	}
+
+	return nil
+}
+
+func (c *memoryCacheable) remove(element *list.Element) {
+	c.order.Remove(element)
+
+	item := element.Value.(*memoryItem)
+	delete(c.items, item.key)
+	c.release(item)
+}
+
+func (c *memoryCacheable) version(tag string) int64 {
+	if version, ok := c.tags[tag]; ok {
+		return version
+	}
+	return c.dropped
+}
+
+func (c *memoryCacheable) snapshot(tags []string) Versions {
+	versions := make(Versions, len(tags))
+	for _, tag := range tags {
+		versions[tag] = c.version(tag)
+	}
+	return versions
+}
+
+// release forgets the tags of item, dropping those no other item carries.
+func (c *memoryCacheable) release(item *memoryItem) {
+	for tag := range item.tags {
+		if c.refs[tag]--; c.refs[tag] <= 0 {
+			c.drop(tag)
+		}
+	}
+}
+
+func (c *memoryCacheable) drop(tag string) {
+	if version := c.tags[tag]; version > c.dropped {
+		c.dropped = version
+	}
+	delete(c.tags, tag)
+	delete(c.refs, tag)
+}
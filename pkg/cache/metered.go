@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type meteredCacheable struct {
+	cache Cacheable
+	hits  prometheus.Counter
+	miss  prometheus.Counter
+	errs  prometheus.Counter
+}
+
+// NewMeteredCacheable counts every Get of cache as a hit, a miss or an error in requests, labeled with name.
+// ErrCacheUnavailable counts as an error, not a miss, so a Redis outage doesn't look like a cold cache.
+func NewMeteredCacheable(cache Cacheable, name string, requests *prometheus.CounterVec) Cacheable {
+	return &meteredCacheable{
+		cache: cache,
+		hits:  requests.WithLabelValues(name, "hit"),
+		miss:  requests.WithLabelValues(name, "miss"),
+		errs:  requests.WithLabelValues(name, "error"),
+	}
+}
+
+func (c *meteredCacheable) Get(ctx context.Context, key string) (string, error) {
+	value, err := c.cache.Get(ctx, key)
+
+	switch {
+	case err == nil:
+		c.hits.Inc()
+	case errors.Is(err, ErrCacheMiss):
+		c.miss.Inc()
+	default:
+		c.errs.Inc()
+	}
+
+	return value, err
+}
+
+func (c *meteredCacheable) Snapshot(ctx context.Context, tags ...string) (Versions, error) {
+	return c.cache.Snapshot(ctx, tags...)
+}
+
+func (c *meteredCacheable) Set(ctx context.Context, key string, value interface{}, expire time.Duration, versions Versions) error {
+	return c.cache.Set(ctx, key, value, expire, versions)
+}
+
+func (c *meteredCacheable) Delete(ctx context.Context, key string) error {
+	return c.cache.Delete(ctx, key)
+}
+
+func (c *meteredCacheable) Invalidate(ctx context.Context, tags ...string) error {
+	return c.cache.Invalidate(ctx, tags...)
+}
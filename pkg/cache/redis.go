@@ -0,0 +1,306 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// retryAfter is how long Redis is skipped after a failed call, so requests don't all wait on a dead connection.
+const retryAfter = 2 * time.Second
+
+// tagExpire is how long a tag version is kept after it was last bumped or stored with an entry. It outlives
+// every tagged entry, Set caps their expiry to it, so a tag version that starts again from 0 can't match an
+// entry cached under its earlier run.
+const tagExpire = 24 * time.Hour
+
+type cacheable struct {
+	Redis  *redis.Client
+	prefix string
+
+	mu        sync.Mutex
+	downUntil time.Time
+	// pending holds tags whose invalidation failed, reads bypass Redis until they are flushed
+	pending map[string]struct{}
+	// flushing counts the flushes in flight per tag, entries with such a tag are treated as missing until
+	// the bump has landed
+	flushing map[string]int
+}
+
+// NewCacheable prefixes every key with namespace and version, bumping the version drops everything cached
+// by older releases, e.g. after the shape of a cached struct changed.
+//
+// While Redis is unreachable every call fails fast with ErrCacheUnavailable. Invalidations made in that time
+// are kept and replayed before Redis is used again, so entries cached before the outage can't be served stale.
+func NewCacheable(redis *redis.Client, namespace string, version string) Cacheable {
+	return &cacheable{Redis: redis, prefix: fmt.Sprintf("%s:%s:", namespace, version), pending: make(map[string]struct{}), flushing: make(map[string]int)}
+}
+
+func (c *cacheable) Snapshot(ctx context.Context, tags ...string) (Versions, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	if !c.ready(ctx) {
+		return nil, ErrCacheUnavailable
+	}
+
+	versions, err := c.tagVersions(ctx, tags)
+	if err != nil {
+		return nil, c.fail(ctx, err)
+	}
+
+	return versions, nil
+}
+
+func (c *cacheable) Set(ctx context.Context, key string, value interface{}, expire time.Duration, versions Versions) error {
+	if !c.ready(ctx) {
+		return ErrCacheUnavailable
+	}
+
+	if len(versions) > 0 {
+		latest, err := c.tagVersions(ctx, versions.Tags())
+		if err != nil {
+			return c.fail(ctx, err)
+		}
+
+		// Get would never return it, and it could replace a value loaded after the invalidation
+		if !versions.current(latest) {
+			return nil
+		}
+	}
+
+	data, err := json.Marshal(entry{Value: toString(value), Tags: versions})
+	if err != nil {
+		return err
+	}
+
+	if len(versions) == 0 {
+		if err := c.Redis.Set(ctx, c.key(key), data, expire).Err(); err != nil {
+			return c.fail(ctx, err)
+		}
+		return nil
+	}
+
+	// an entry mustn't outlive the versions it was checked against, the loader's expiry already includes
+	// its stale window
+	if expire <= 0 || expire > tagExpire {
+		expire = tagExpire
+	}
+
+	_, err = c.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, c.key(key), data, expire)
+		for tag := range versions {
+			pipe.Expire(ctx, c.tagKey(tag), tagExpire)
+		}
+		return nil
+	})
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+
+	return nil
+}
+
+func (c *cacheable) Get(ctx context.Context, key string) (string, error) {
+	if !c.ready(ctx) {
+		return "", ErrCacheUnavailable
+	}
+
+	val, err := c.Redis.Get(ctx, c.key(key)).Result()
+
+	if err == redis.Nil {
+		return "", ErrCacheMiss
+	}
+
+	if err != nil {
+		return "", c.fail(ctx, err)
+	}
+
+	var e entry
+	if err := json.Unmarshal([]byte(val), &e); err != nil {
+		return "", ErrCacheMiss
+	}
+
+	if len(e.Tags) == 0 {
+		return e.Value, nil
+	}
+
+	versions := Versions(e.Tags)
+
+	// another call is still bumping one of its tags
+	if c.unflushed(versions) {
+		return "", ErrCacheMiss
+	}
+
+	latest, err := c.tagVersions(ctx, versions.Tags())
+	if err != nil {
+		return "", c.fail(ctx, err)
+	}
+
+	if !versions.current(latest) {
+		return "", ErrCacheMiss
+	}
+
+	return e.Value, nil
+}
+
+func (c *cacheable) Delete(ctx context.Context, key string) error {
+	if !c.ready(ctx) {
+		return ErrCacheUnavailable
+	}
+
+	if err := c.Redis.Del(ctx, c.key(key)).Err(); err != nil {
+		return c.fail(ctx, err)
+	}
+
+	return nil
+}
+
+// Invalidate bumps the version of every tag, entries cached under an older version are treated as missing.
+// When Redis is down the tags are queued instead, so a write never fails because of the cache. The write
+// already happened, so the invalidation isn't cancelled with ctx.
+func (c *cacheable) Invalidate(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	for _, tag := range tags {
+		c.pending[tag] = struct{}{}
+	}
+	c.mu.Unlock()
+
+	c.ready(context.WithoutCancel(ctx))
+
+	return nil
+}
+
+// ready reports whether Redis may be used, it flushes queued invalidations first. The flush runs without
+// holding the lock, so a slow Redis doesn't serialize every other call behind it.
+func (c *cacheable) ready(ctx context.Context) bool {
+	c.mu.Lock()
+
+	if time.Now().Before(c.downUntil) {
+		c.mu.Unlock()
+		return false
+	}
+
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return true
+	}
+
+	tags := c.pending
+	c.pending = make(map[string]struct{})
+	for tag := range tags {
+		c.flushing[tag]++
+	}
+	c.mu.Unlock()
+
+	_, err := c.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for tag := range tags {
+			pipe.Incr(ctx, c.tagKey(tag))
+			pipe.Expire(ctx, c.tagKey(tag), tagExpire)
+		}
+		return nil
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for tag := range tags {
+		if c.flushing[tag]--; c.flushing[tag] == 0 {
+			delete(c.flushing, tag)
+		}
+	}
+
+	if err != nil {
+		// queued again, nothing may be read until they are flushed
+		for tag := range tags {
+			c.pending[tag] = struct{}{}
+		}
+		c.markDown(ctx, err)
+		return false
+	}
+
+	return true
+}
+
+// unflushed reports whether an invalidation of one of tags hasn't reached Redis yet.
+func (c *cacheable) unflushed(tags Versions) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for tag := range tags {
+		if _, ok := c.pending[tag]; ok {
+			return true
+		}
+		if c.flushing[tag] > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *cacheable) fail(ctx context.Context, err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.markDown(ctx, err)
+
+	return fmt.Errorf("%w: %v", ErrCacheUnavailable, err)
+}
+
+// markDown skips Redis for retryAfter, unless the call only failed because ctx was cancelled or timed out.
+func (c *cacheable) markDown(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	if c.downUntil.IsZero() || time.Now().After(c.downUntil.Add(retryAfter)) {
+		slog.WarnContext(ctx, "redis unavailable, reading from the database", "error", err)
+	}
+	c.downUntil = time.Now().Add(retryAfter)
+}
+
+func (c *cacheable) tagVersions(ctx context.Context, tags []string) (Versions, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = c.tagKey(tag)
+	}
+
+	values, err := c.Redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(Versions, len(tags))
+	for i, tag := range tags {
+		versions[tag] = 0
+		if value, ok := values[i].(string); ok {
+			versions[tag], _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+
+	return versions, nil
+}
+
+func (c *cacheable) key(key string) string {
+	return c.prefix + key
+}
+
+func (c *cacheable) tagKey(tag string) string {
+	return c.prefix + "tag:" + tag
+}
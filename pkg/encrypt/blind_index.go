@@ -0,0 +1,39 @@
+package encrypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// BlindIndex derives a deterministic lookup value from a plaintext, so an encrypted column can still be
+// searched by equality without storing the plaintext.
+type BlindIndex interface {
+	Index(text string) string
+}
+
+type blindIndex struct {
+	key []byte
+}
+
+// NewBlindIndex uses HMAC-SHA256 under key, which must differ from the encryption key. Changing it
+// invalidates every stored index.
+func NewBlindIndex(key string) (BlindIndex, error) {
+	if len(key) < 16 {
+		return nil, errors.New("blind index key must be at least 16 bytes")
+	}
+
+	return &blindIndex{key: []byte(key)}, nil
+}
+
+func (b *blindIndex) Index(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write([]byte(text))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -5,9 +5,12 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"encoding/base64"
+	"errors"
 	"fmt"
 )
 
+var ErrInvalidPadding = errors.New("invalid padding")
+
 type EncryptTool interface {
 	Encrypt(text string) (string, error)
 	Decrypt(text string) (string, error)
@@ -29,24 +32,17 @@ func (e *encryptTool) Encrypt(text string) (string, error) {
 	key := e.secretKey
 	iv := e.iv
 
-	var plainTextBlock []byte
-	length := len(text)
-
-	if length % 16 != 0 {
-		extendBlock := 16 - (length % 16)
-		plainTextBlock = make([]byte, length+extendBlock)
-		copy(plainTextBlock[length:], bytes.Repeat([]byte{uint8(extendBlock)}, extendBlock))
-	} else {
-		plainTextBlock = make([]byte, length)
-	}
-
-	copy(plainTextBlock, text)
+	plainTextBlock := PKCS5Padding([]byte(text), aes.BlockSize)
 	block, err := aes.NewCipher([]byte(key))
 
 	if err != nil {
 		return "", err
 	}
 
+	if len(iv) != aes.BlockSize {
+		return "", fmt.Errorf("iv must be %d bytes", aes.BlockSize)
+	}
+
 	ciphertext := make([]byte, len(plainTextBlock))
 	mode := cipher.NewCBCEncrypter(block, []byte(iv))
 	mode.CryptBlocks(ciphertext, plainTextBlock)
@@ -72,21 +68,50 @@ func (e *encryptTool) Decrypt(text string) (string, error) {
 		return "", err
 	}
 
-	if len(ciphertext)%aes.BlockSize != 0 {
-		return "", fmt.Errorf("block size cant be zero")
+	if len(iv) != aes.BlockSize {
+		return "", fmt.Errorf("iv must be %d bytes", aes.BlockSize)
+	}
+
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return "", fmt.Errorf("ciphertext is not a multiple of the block size")
 	}
 
 	mode := cipher.NewCBCDecrypter(block, []byte(iv))
 	mode.CryptBlocks(ciphertext, ciphertext)
-	ciphertext = PKCS5UnPadding(ciphertext)
+	ciphertext, err = PKCS5UnPadding(ciphertext)
+
+	if err != nil {
+		return "", err
+	}
 
 	return string(ciphertext), nil
 }
 
-// PKCS5UnPadding  pads a certain blob of data with necessary data to be used in AES block cipher
-func PKCS5UnPadding(src []byte) []byte {
+// PKCS5Padding pads src to a multiple of blockSize, a full block is added when it already is one
+// so the padding can always be removed unambiguously.
+func PKCS5Padding(src []byte, blockSize int) []byte {
+	padding := blockSize - len(src)%blockSize
+
+	return append(src, bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
+
+// PKCS5UnPadding removes the padding added by PKCS5Padding, malformed padding is an error rather than a panic.
+func PKCS5UnPadding(src []byte) ([]byte, error) {
 	length := len(src)
+	if length == 0 || length%aes.BlockSize != 0 {
+		return nil, ErrInvalidPadding
+	}
+
 	unpadding := int(src[length-1])
+	if unpadding == 0 || unpadding > aes.BlockSize {
+		return nil, ErrInvalidPadding
+	}
+
+	for _, b := range src[length-unpadding:] {
+		if int(b) != unpadding {
+			return nil, ErrInvalidPadding
+		}
+	}
 
-	return src[:(length - unpadding)]
+	return src[:(length - unpadding)], nil
 }
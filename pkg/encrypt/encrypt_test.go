@@ -0,0 +1,54 @@
+package encrypt
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPKCS5UnPadding(t *testing.T) {
+	for _, length := range []int{0, 1, 15, 16, 17, 32} {
+		src := bytes.Repeat([]byte("a"), length)
+
+		got, err := PKCS5UnPadding(PKCS5Padding(append([]byte(nil), src...), 16))
+		if err != nil || !bytes.Equal(got, src) {
+			t.Fatalf("PKCS5UnPadding(PKCS5Padding(%d bytes)) = %q, %v", length, got, err)
+		}
+	}
+
+	invalid := map[string][]byte{
+		"empty":              {},
+		"not a block":        bytes.Repeat([]byte{1}, 15),
+		"zero padding":       append(bytes.Repeat([]byte("a"), 15), 0),
+		"padding over block": append(bytes.Repeat([]byte("a"), 15), 17),
+		"inconsistent":       append(bytes.Repeat([]byte("a"), 14), 3, 2),
+	}
+
+	for name, src := range invalid {
+		if _, err := PKCS5UnPadding(src); !errors.Is(err, ErrInvalidPadding) {
+			t.Fatalf("PKCS5UnPadding(%s) error = %v, want ErrInvalidPadding", name, err)
+		}
+	}
+}
+
+func TestEncryptToolRejectsMalformedCiphertext(t *testing.T) {
+	tool := NewEncryptTool(testKey, "0123456789abcdef")
+
+	ciphertext, err := tool.Encrypt("0123456789abcdef")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if plaintext, err := tool.Decrypt(ciphertext); err != nil || plaintext != "0123456789abcdef" {
+		t.Fatalf("Decrypt() = %q, %v", plaintext, err)
+	}
+
+	for _, text := range []string{"", "AAAA", "not base64"} {
+		if _, err := tool.Decrypt(text); err == nil {
+			t.Fatalf("Decrypt(%q) error = nil", text)
+		}
+	}
+
+	if _, err := NewEncryptTool(testKey, "short").Decrypt(ciphertext); err == nil {
+		t.Fatal("Decrypt() with a short iv error = nil")
+	}
+}
@@ -0,0 +1,165 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// ciphertextPrefix marks a value written by the GCM tool, values without it are legacy plaintext.
+	ciphertextPrefix = "enc:"
+	// boundPrefix marks a value sealed together with its binding, values with ciphertextPrefix only carry the
+	// key version in their additional data.
+	boundPrefix = "encb:"
+)
+
+var (
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+	ErrUnknownKeyVersion = errors.New("unknown encryption key version")
+)
+
+type gcmTool struct {
+	version string
+	aead    cipher.AEAD
+}
+
+// BindingTool seals values together with a binding naming where they are stored, see Binding. A value copied
+// to another row or column then no longer decrypts.
+type BindingTool interface {
+	EncryptTool
+	EncryptBound(text string, binding string) (string, error)
+	// DecryptBound opens values sealed by EncryptBound under the same binding. Values sealed by Encrypt carry no
+	// binding and are opened as they are.
+	DecryptBound(text string, binding string) (string, error)
+}
+
+// Binding names the column of a row a value is stored in, e.g. "users.address:<id>".
+func Binding(table string, column string, id interface{}) string {
+	return fmt.Sprintf("%s.%s:%v", table, column, id)
+}
+
+// NewGCMTool encrypts with AES-GCM under a random nonce per value. secretKey must be 16, 24 or 32 bytes.
+//
+// Ciphertexts look like "enc:<version>:<base64(nonce|sealed)>", the version names the key that sealed the value
+// so values written under an older key can still be told apart after a rotation. Bound ciphertexts look like
+// "encb:<version>:<base64(nonce|sealed)>" and also authenticate their binding.
+func NewGCMTool(version string, secretKey string) (BindingTool, error) {
+	if version == "" || strings.Contains(version, ":") {
+		return nil, fmt.Errorf("invalid encryption key version %q", version)
+	}
+
+	block, err := aes.NewCipher([]byte(secretKey))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcmTool{version: version, aead: aead}, nil
+}
+
+func (e *gcmTool) Encrypt(text string) (string, error) {
+	return e.seal(ciphertextPrefix, text, e.version)
+}
+
+// Decrypt refuses bound values, they can only be opened with their binding.
+func (e *gcmTool) Decrypt(text string) (string, error) {
+	if IsBound(text) {
+		return "", ErrInvalidCiphertext
+	}
+
+	return e.open(text, e.version)
+}
+
+// EncryptBound seals the version and binding as additional data, versions never contain ':' so the two can't
+// be confused.
+func (e *gcmTool) EncryptBound(text string, binding string) (string, error) {
+	return e.seal(boundPrefix, text, e.version+":"+binding)
+}
+
+func (e *gcmTool) DecryptBound(text string, binding string) (string, error) {
+	if !IsBound(text) {
+		return e.open(text, e.version)
+	}
+
+	return e.open(text, e.version+":"+binding)
+}
+
+func (e *gcmTool) seal(prefix string, text string, additionalData string) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := e.aead.Seal(nonce, nonce, []byte(text), []byte(additionalData))
+
+	return prefix + e.version + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func (e *gcmTool) open(text string, additionalData string) (string, error) {
+	version, data, err := ParseCiphertext(text)
+	if err != nil {
+		return "", err
+	}
+
+	if version != e.version {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKeyVersion, version)
+	}
+
+	if len(data) < e.aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+
+	nonce, sealed := data[:e.aead.NonceSize()], data[e.aead.NonceSize():]
+
+	plaintext, err := e.aead.Open(nil, nonce, sealed, []byte(additionalData))
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	return string(plaintext), nil
+}
+
+// IsCiphertext reports whether text was written by an encrypting tool rather than stored as plaintext.
+func IsCiphertext(text string) bool {
+	return strings.HasPrefix(text, ciphertextPrefix) || IsBound(text)
+}
+
+// IsBound reports whether text was sealed together with a binding.
+func IsBound(text string) bool {
+	return strings.HasPrefix(text, boundPrefix)
+}
+
+// ParseCiphertext splits a ciphertext into the version of its key and the sealed bytes.
+func ParseCiphertext(text string) (string, []byte, error) {
+	var rest string
+
+	switch {
+	case IsBound(text):
+		rest = strings.TrimPrefix(text, boundPrefix)
+	case IsCiphertext(text):
+		rest = strings.TrimPrefix(text, ciphertextPrefix)
+	default:
+		return "", nil, ErrInvalidCiphertext
+	}
+
+	version, encoded, ok := strings.Cut(rest, ":")
+	if !ok || version == "" {
+		return "", nil, ErrInvalidCiphertext
+	}
+
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, ErrInvalidCiphertext
+	}
+
+	return version, data, nil
+}
@@ -0,0 +1,131 @@
+package encrypt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testKey = "0123456789abcdef0123456789abcdef"
+
+func TestGCMToolRoundTrip(t *testing.T) {
+	tool, err := NewGCMTool("1", testKey)
+	if err != nil {
+		t.Fatalf("NewGCMTool() error = %v", err)
+	}
+
+	first, err := tool.Encrypt("+6281234567890")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	second, _ := tool.Encrypt("+6281234567890")
+
+	if !strings.HasPrefix(first, "enc:1:") {
+		t.Fatalf("Encrypt() = %q, want the key version prefix", first)
+	}
+	if first == second {
+		t.Fatal("Encrypt() returned the same ciphertext twice, want a random nonce per value")
+	}
+
+	plaintext, err := tool.Decrypt(first)
+	if err != nil || plaintext != "+6281234567890" {
+		t.Fatalf("Decrypt() = %q, %v", plaintext, err)
+	}
+}
+
+func TestGCMToolRejectsInvalidCiphertext(t *testing.T) {
+	tool, _ := NewGCMTool("1", testKey)
+	other, _ := NewGCMTool("2", testKey)
+
+	ciphertext, _ := tool.Encrypt("Jl. Merdeka 1")
+
+	if _, err := other.Decrypt(ciphertext); !errors.Is(err, ErrUnknownKeyVersion) {
+		t.Fatalf("Decrypt() with another key version error = %v, want ErrUnknownKeyVersion", err)
+	}
+
+	// flip the last character of the sealed data, the tag check must catch it
+	tampered := ciphertext[:len(ciphertext)-1] + "A"
+	if tampered == ciphertext {
+		tampered = ciphertext[:len(ciphertext)-1] + "B"
+	}
+
+	for _, text := range []string{"", "plaintext", "enc:", "enc:1:", "enc:1:!!!", "enc:1:AAAA", tampered} {
+		if _, err := tool.Decrypt(text); err == nil {
+			t.Fatalf("Decrypt(%q) error = nil, want an error", text)
+		}
+	}
+}
+
+func TestGCMToolBindsValues(t *testing.T) {
+	tool, _ := NewGCMTool("1", testKey)
+
+	address := Binding("users", "address", "00000000-0000-0000-0000-000000000001")
+	ciphertext, err := tool.EncryptBound("Jl. Merdeka 1", address)
+	if err != nil {
+		t.Fatalf("EncryptBound() error = %v", err)
+	}
+	if !strings.HasPrefix(ciphertext, "encb:1:") || !IsCiphertext(ciphertext) {
+		t.Fatalf("EncryptBound() = %q, want a bound ciphertext", ciphertext)
+	}
+
+	if plaintext, err := tool.DecryptBound(ciphertext, address); err != nil || plaintext != "Jl. Merdeka 1" {
+		t.Fatalf("DecryptBound() = %q, %v", plaintext, err)
+	}
+
+	// copied to another row or another column of the same row
+	for _, binding := range []string{
+		Binding("users", "address", "00000000-0000-0000-0000-000000000002"),
+		Binding("users", "phone_number", "00000000-0000-0000-0000-000000000001"),
+		Binding("companies", "address", "00000000-0000-0000-0000-000000000001"),
+	} {
+		if _, err := tool.DecryptBound(ciphertext, binding); !errors.Is(err, ErrInvalidCiphertext) {
+			t.Fatalf("DecryptBound() under %q error = %v, want ErrInvalidCiphertext", binding, err)
+		}
+	}
+	if _, err := tool.Decrypt(ciphertext); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Fatalf("Decrypt() of a bound value error = %v, want ErrInvalidCiphertext", err)
+	}
+
+	// values sealed before they were bound are still readable
+	unbound, _ := tool.Encrypt("Jl. Merdeka 1")
+	if plaintext, err := tool.DecryptBound(unbound, address); err != nil || plaintext != "Jl. Merdeka 1" {
+		t.Fatalf("DecryptBound() of an unbound value = %q, %v", plaintext, err)
+	}
+}
+
+func TestNewGCMToolValidatesKey(t *testing.T) {
+	if _, err := NewGCMTool("1", "short"); err == nil {
+		t.Fatal("NewGCMTool() with a 5 byte key error = nil")
+	}
+	if _, err := NewGCMTool("", testKey); err == nil {
+		t.Fatal("NewGCMTool() without a version error = nil")
+	}
+	if _, err := NewGCMTool("1:2", testKey); err == nil {
+		t.Fatal("NewGCMTool() with ':' in the version error = nil")
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	index, err := NewBlindIndex("test-blind-index-key")
+	if err != nil {
+		t.Fatalf("NewBlindIndex() error = %v", err)
+	}
+	other, _ := NewBlindIndex("other-blind-index-key")
+
+	if index.Index("+6281234567890") != index.Index("+6281234567890") {
+		t.Fatal("Index() is not deterministic")
+	}
+	if index.Index("+6281234567890") == index.Index("+6289876543210") {
+		t.Fatal("Index() of different values collide")
+	}
+	if index.Index("+6281234567890") == other.Index("+6281234567890") {
+		t.Fatal("Index() doesn't depend on the key")
+	}
+	if index.Index("") != "" {
+		t.Fatal("Index() of an empty value should stay empty")
+	}
+
+	if _, err := NewBlindIndex("short"); err == nil {
+		t.Fatal("NewBlindIndex() with a short key error = nil")
+	}
+}
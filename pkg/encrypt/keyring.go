@@ -0,0 +1,96 @@
+package encrypt
+
+import (
+	"fmt"
+)
+
+// Keyring decrypts values sealed under any of its key versions and encrypts under the primary one, so a key
+// can be rotated while values sealed under the previous key are still being read.
+type Keyring interface {
+	BindingTool
+	// Primary is the key version new values are encrypted under.
+	Primary() string
+	// NeedsReencrypt reports whether text is plaintext, isn't bound to where it is stored or was sealed under a
+	// key other than the primary one.
+	NeedsReencrypt(text string) bool
+}
+
+type keyring struct {
+	primary string
+	tools   map[string]BindingTool
+}
+
+// NewKeyring builds one GCM tool per entry of keys, which maps a key version to its secret and must contain
+// primary.
+func NewKeyring(primary string, keys map[string]string) (Keyring, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("primary encryption key version %q has no key", primary)
+	}
+
+	tools := make(map[string]BindingTool, len(keys))
+
+	for version, key := range keys {
+		tool, err := NewGCMTool(version, key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key version %q: %w", version, err)
+		}
+		tools[version] = tool
+	}
+
+	return &keyring{primary: primary, tools: tools}, nil
+}
+
+func (k *keyring) Primary() string {
+	return k.primary
+}
+
+func (k *keyring) Encrypt(text string) (string, error) {
+	return k.tools[k.primary].Encrypt(text)
+}
+
+func (k *keyring) Decrypt(text string) (string, error) {
+	tool, err := k.toolOf(text)
+	if err != nil {
+		return "", err
+	}
+
+	return tool.Decrypt(text)
+}
+
+func (k *keyring) EncryptBound(text string, binding string) (string, error) {
+	return k.tools[k.primary].EncryptBound(text, binding)
+}
+
+func (k *keyring) DecryptBound(text string, binding string) (string, error) {
+	tool, err := k.toolOf(text)
+	if err != nil {
+		return "", err
+	}
+
+	return tool.DecryptBound(text, binding)
+}
+
+// toolOf returns the tool of the key text was sealed under.
+func (k *keyring) toolOf(text string) (BindingTool, error) {
+	version, _, err := ParseCiphertext(text)
+	if err != nil {
+		return nil, err
+	}
+
+	tool, ok := k.tools[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKeyVersion, version)
+	}
+
+	return tool, nil
+}
+
+func (k *keyring) NeedsReencrypt(text string) bool {
+	if text == "" {
+		return false
+	}
+
+	version, _, err := ParseCiphertext(text)
+
+	return err != nil || !IsBound(text) || version != k.primary
+}
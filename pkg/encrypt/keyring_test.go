@@ -0,0 +1,54 @@
+package encrypt
+
+import (
+	"errors"
+	"testing"
+)
+
+const newKey = "fedcba9876543210fedcba9876543210"
+
+func TestKeyringDecryptsEveryVersion(t *testing.T) {
+	old, _ := NewGCMTool("1", testKey)
+	sealedUnderOld, _ := old.Encrypt("Jl. Merdeka 1")
+
+	keyring, err := NewKeyring("2", map[string]string{"1": testKey, "2": newKey})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	if plaintext, err := keyring.Decrypt(sealedUnderOld); err != nil || plaintext != "Jl. Merdeka 1" {
+		t.Fatalf("Decrypt() of a value under the old key = %q, %v", plaintext, err)
+	}
+
+	binding := Binding("users", "address", 1)
+	sealed, _ := keyring.EncryptBound("Jl. Merdeka 1", binding)
+	if version, _, _ := ParseCiphertext(sealed); version != "2" {
+		t.Fatalf("EncryptBound() sealed under version %q, want the primary 2", version)
+	}
+	if plaintext, err := keyring.DecryptBound(sealed, binding); err != nil || plaintext != "Jl. Merdeka 1" {
+		t.Fatalf("DecryptBound() = %q, %v", plaintext, err)
+	}
+
+	unbound, _ := keyring.Encrypt("Jl. Merdeka 1")
+	if !keyring.NeedsReencrypt(sealedUnderOld) || !keyring.NeedsReencrypt("plaintext") || !keyring.NeedsReencrypt(unbound) {
+		t.Fatal("NeedsReencrypt() = false for a value not bound under the primary key")
+	}
+	if keyring.NeedsReencrypt(sealed) || keyring.NeedsReencrypt("") {
+		t.Fatal("NeedsReencrypt() = true for a value that needs nothing")
+	}
+
+	unknown, _ := NewGCMTool("3", testKey)
+	sealedUnderUnknown, _ := unknown.Encrypt("Jl. Merdeka 1")
+	if _, err := keyring.Decrypt(sealedUnderUnknown); !errors.Is(err, ErrUnknownKeyVersion) {
+		t.Fatalf("Decrypt() under a missing key error = %v, want ErrUnknownKeyVersion", err)
+	}
+}
+
+func TestNewKeyringRequiresPrimaryKey(t *testing.T) {
+	if _, err := NewKeyring("2", map[string]string{"1": testKey}); err == nil {
+		t.Fatal("NewKeyring() without the primary key error = nil")
+	}
+	if _, err := NewKeyring("1", map[string]string{"1": testKey, "2": "short"}); err == nil {
+		t.Fatal("NewKeyring() with an invalid key error = nil")
+	}
+}
@@ -0,0 +1,87 @@
+package encrypt
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is used in struct tags, e.g. `gorm:"serializer:encrypted"`.
+const SerializerName = "encrypted"
+
+type serializer struct {
+	tool BindingTool
+}
+
+// RegisterSerializer makes string columns tagged with SerializerName encrypted by tool, bound to their table,
+// column and the primary key of their row. It has to be called before the first query touching such a column,
+// and queries have to select the primary key before the encrypted columns.
+func RegisterSerializer(tool BindingTool) {
+	schema.RegisterSerializer(SerializerName, serializer{tool: tool})
+}
+
+// Scan decrypts the column, values stored before encryption was enabled are read as they are.
+func (s serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var text string
+
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("encrypted column %s: unsupported value %T", field.Name, dbValue)
+	}
+
+	if IsCiphertext(text) {
+		binding, err := bindingOf(ctx, field, dst)
+		if err != nil && IsBound(text) {
+			return err
+		}
+
+		plaintext, err := s.tool.DecryptBound(text, binding)
+		if err != nil {
+			return fmt.Errorf("encrypted column %s: %w", field.Name, err)
+		}
+		text = plaintext
+	}
+
+	return field.Set(ctx, dst, text)
+}
+
+func (s serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	text, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted column %s: unsupported type %T", field.Name, fieldValue)
+	}
+
+	if text == "" {
+		return "", nil
+	}
+
+	binding, err := bindingOf(ctx, field, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tool.EncryptBound(text, binding)
+}
+
+// bindingOf binds the column to the primary key of the row in dst. The key has to be set already, by the
+// caller or BeforeCreate when writing and by an earlier column when reading.
+func bindingOf(ctx context.Context, field *schema.Field, dst reflect.Value) (string, error) {
+	primaryKey := field.Schema.PrioritizedPrimaryField
+	if primaryKey == nil {
+		return "", fmt.Errorf("encrypted column %s: %s has no primary key", field.Name, field.Schema.Name)
+	}
+
+	id, zero := primaryKey.ValueOf(ctx, dst)
+	if zero {
+		return "", fmt.Errorf("encrypted column %s: %s is not set", field.Name, primaryKey.Name)
+	}
+
+	return Binding(field.Schema.Table, field.DBName, id), nil
+}
@@ -0,0 +1,138 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const errUnavailable = "unavailable"
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+	StatusDraining = "draining"
+)
+
+// Check pings a single dependency. A failing Check that isn't Critical only degrades readiness, the
+// instance keeps serving traffic.
+type Check struct {
+	Name     string
+	Critical bool
+	Ping     func(ctx context.Context) error
+}
+
+// CheckResult is served on the public /readyz, Error is a fixed text, the cause of a failure is only logged.
+type CheckResult struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Checker answers readiness probes. Once Drain is called it reports not ready without pinging anything,
+// so load balancers stop routing to the instance before it stops accepting connections.
+type Checker interface {
+	Ready(ctx context.Context) (*Report, bool)
+	Drain()
+}
+
+type checker struct {
+	checks   []Check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// NewChecker runs checks concurrently, each one bounded by its own timeout.
+func NewChecker(timeout time.Duration, checks ...Check) Checker {
+	return &checker{checks: checks, timeout: timeout}
+}
+
+func (c *checker) Ready(ctx context.Context) (*Report, bool) {
+	if c.draining.Load() {
+		return &Report{Status: StatusDraining}, false
+	}
+
+	report := &Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(c.checks))}
+	ready := true
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			result := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[check.Name] = result
+			if result.Status == StatusUp {
+				return
+			}
+			if check.Critical {
+				report.Status, ready = StatusDown, false
+			} else if ready {
+				report.Status = StatusDegraded
+			}
+		}(check)
+	}
+
+	wg.Wait()
+
+	return report, ready
+}
+
+func (c *checker) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Ping(ctx)
+	result := CheckResult{Status: StatusUp, Latency: time.Since(start).Round(time.Microsecond).String()}
+
+	if err != nil {
+		// driver errors name hosts, ports and users, they stay in the log
+		slog.ErrorContext(ctx, "health check failed", "check", check.Name, "error", err)
+		result.Status, result.Error = StatusDown, errUnavailable
+	}
+
+	return result
+}
+
+func (c *checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Postgres is critical, no request can be served without the database.
+func Postgres(db *gorm.DB) Check {
+	return Check{Name: "postgres", Critical: true, Ping: func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}}
+}
+
+// Redis isn't critical, the cache falls back to the database while Redis is unavailable.
+func Redis(client *redis.Client) Check {
+	return Check{Name: "redis", Ping: func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}}
+}
@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func ping(err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return err
+	}
+}
+
+func TestCheckerReady(t *testing.T) {
+	down := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		checks     []Check
+		wantReady  bool
+		wantStatus string
+	}{
+		{name: "all up", checks: []Check{{Name: "db", Critical: true, Ping: ping(nil)}, {Name: "cache", Ping: ping(nil)}}, wantReady: true, wantStatus: StatusUp},
+		{name: "optional down", checks: []Check{{Name: "db", Critical: true, Ping: ping(nil)}, {Name: "cache", Ping: ping(down)}}, wantReady: true, wantStatus: StatusDegraded},
+		{name: "critical down", checks: []Check{{Name: "db", Critical: true, Ping: ping(down)}, {Name: "cache", Ping: ping(down)}}, wantReady: false, wantStatus: StatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, ready := NewChecker(time.Second, tt.checks...).Ready(context.Background())
+
+			if ready != tt.wantReady || report.Status != tt.wantStatus {
+				t.Fatalf("Ready() = %s, %v, want %s, %v", report.Status, ready, tt.wantStatus, tt.wantReady)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Fatalf("report has %d checks, want %d", len(report.Checks), len(tt.checks))
+			}
+			for _, check := range tt.checks {
+				if result := report.Checks[check.Name]; (result.Error != "") != (result.Status == StatusDown) {
+					t.Fatalf("check %s = %+v, want the error only on a failed check", check.Name, result)
+				}
+				if result := report.Checks[check.Name]; result.Error != "" && result.Error != "unavailable" {
+					t.Fatalf("check %s error = %q, want the cause kept out of the report", check.Name, result.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckerTimesOutEachCheck(t *testing.T) {
+	hang := Check{Name: "db", Critical: true, Ping: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	start := time.Now()
+	report, ready := NewChecker(50*time.Millisecond, hang, Check{Name: "cache", Ping: ping(nil)}).Ready(context.Background())
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Ready() took %s, want it bounded by the check timeout", elapsed)
+	}
+	if ready || report.Checks["db"].Status != StatusDown || report.Checks["cache"].Status != StatusUp {
+		t.Fatalf("Ready() = %+v, %v, want only the hanging check down", report, ready)
+	}
+}
+
+func TestCheckerDrain(t *testing.T) {
+	pinged := false
+	checker := NewChecker(time.Second, Check{Name: "db", Critical: true, Ping: func(ctx context.Context) error {
+		pinged = true
+		return nil
+	}})
+
+	checker.Drain()
+
+	report, ready := checker.Ready(context.Background())
+	if ready || report.Status != StatusDraining || pinged {
+		t.Fatalf("Ready() after Drain() = %+v, %v, want draining without pinging", report, ready)
+	}
+}
+
+func TestRedisCheck(t *testing.T) {
+	mr := miniredis.RunT(t)
+	check := Redis(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	if err := check.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+
+	mr.Close()
+
+	if err := check.Ping(context.Background()); err == nil {
+		t.Fatal("Ping() succeeded with Redis stopped")
+	}
+}
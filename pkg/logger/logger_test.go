@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+
+	record := make(map[string]interface{})
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log line %q isn't JSON: %v", buf.String(), err)
+	}
+	buf.Reset()
+
+	return record
+}
+
+func TestLoggerRedactsSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLogger("dev", &buf)
+
+	log.Info("login", "email", "budi@example.com", "password", "hunter2", "refresh_token", "abc", slog.Group("header", "Authorization", "Bearer abc"))
+
+	record := decode(t, &buf)
+	if record["password"] != redacted || record["refresh_token"] != redacted {
+		t.Fatalf("record = %v, want password and refresh_token redacted", record)
+	}
+	if header := record["header"].(map[string]interface{}); header["Authorization"] != redacted {
+		t.Fatalf("header = %v, want Authorization redacted inside the group", header)
+	}
+	if record["email"] != "budi@example.com" {
+		t.Fatalf("email = %v, want it kept", record["email"])
+	}
+}
+
+func TestLoggerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLogger("dev", &buf).With("component", "test")
+
+	log.InfoContext(WithRequestID(context.Background(), "req-1"), "with request")
+	if record := decode(t, &buf); record["request_id"] != "req-1" {
+		t.Fatalf("request_id = %v, want req-1", record["request_id"])
+	}
+
+	log.InfoContext(context.Background(), "without request")
+	if _, ok := decode(t, &buf)["request_id"]; ok {
+		t.Fatal("request_id logged without a request")
+	}
+}
+
+func TestLoggerAddsTrace(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLogger("dev", &buf)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	span := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+
+	log.InfoContext(trace.ContextWithSpanContext(context.Background(), span), "traced")
+	record := decode(t, &buf)
+	if record["trace_id"] != traceID.String() || record["span_id"] != spanID.String() {
+		t.Fatalf("record = %v, want trace_id and span_id of the span", record)
+	}
+
+	log.InfoContext(context.Background(), "untraced")
+	if _, ok := decode(t, &buf)["trace_id"]; ok {
+		t.Fatal("trace_id logged without a trace")
+	}
+}
+
+func TestLoggerLevelFollowsEnv(t *testing.T) {
+	for env, wantDebug := range map[string]bool{"dev": true, "production": false} {
+		var buf bytes.Buffer
+		NewLogger(env, &buf).Debug("query")
+
+		if logged := buf.Len() > 0; logged != wantDebug {
+			t.Fatalf("debug logged in %s = %v, want %v", env, logged, wantDebug)
+		}
+	}
+}
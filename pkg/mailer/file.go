@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileMailer struct {
+	path string
+	from string
+	mu   sync.Mutex
+}
+
+// NewFileMailer appends every message to path instead of delivering it. An empty path writes the headers to the
+// log but not the body, bodies hold reset and verification links and logs are shipped and kept elsewhere.
+func NewFileMailer(path string, from string) Mailer {
+	return &fileMailer{path: path, from: from}
+}
+
+func (m *fileMailer) Send(ctx context.Context, message Message) error {
+	headers := fmt.Sprintf("Date: %s\nFrom: %s\nTo: %s\nSubject: %s\n%s", time.Now().Format(time.RFC1123Z), m.from, message.To, message.Subject, traceHeaders(ctx, "\n"))
+
+	if m.path == "" {
+		log.Print(headers + "\n(body omitted, use MAIL_DRIVER=file to read it)\n\n")
+		return nil
+	}
+
+	entry := headers + "\n" + message.Body + "\n\n"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(entry)
+	return err
+}
@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/textproto"
+	"sort"
+
+	"github.com/DavidAfdal/workfinder/config"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends message, the trace of ctx goes along as traceparent and tracestate headers.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// NewMailer picks the implementation from MAIL_DRIVER, "smtp" for real delivery and "file" or "log" for local use.
+// Without MAIL_DRIVER the dev env logs mails and any other env refuses to start, so mails aren't silently lost.
+func NewMailer(env string, config *config.MailConfig) (Mailer, error) {
+	driver := config.Driver
+	if driver == "" && env == "dev" {
+		driver = "log"
+	}
+
+	switch driver {
+	case "smtp":
+		return NewSMTPMailer(config.Host, config.Port, config.Username, config.Password, config.From), nil
+	case "file":
+		return NewFileMailer(config.FilePath, config.From), nil
+	case "log":
+		return NewFileMailer("", config.From), nil
+	case "":
+		return nil, fmt.Errorf("MAIL_DRIVER is required in env %q", env)
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", config.Driver)
+	}
+}
+
+// traceHeaders formats the trace of ctx as mail header lines. Only the trace context is written, baggage may
+// hold values that shouldn't leave the system.
+func traceHeaders(ctx context.Context, newline string) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	keys := carrier.Keys()
+	sort.Strings(keys)
+
+	var headers string
+	for _, key := range keys {
+		headers += fmt.Sprintf("%s: %s%s", textproto.CanonicalMIMEHeaderKey(key), carrier.Get(key), newline)
+	}
+
+	return headers
+}
@@ -0,0 +1,71 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DavidAfdal/workfinder/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestFileMailerWritesTraceparent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m := NewFileMailer(path, "no-reply@workfinder.id")
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled,
+	}))
+
+	if err := m.Send(ctx, Message{To: "budi@example.com", Subject: "Verify your email", Body: "hi"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if err := m.Send(context.Background(), Message{To: "budi@example.com", Subject: "Untraced", Body: "hi"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	want := "Subject: Verify your email\nTraceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01\n\nhi"
+	if !strings.Contains(string(data), want) {
+		t.Fatalf("mail = %q, want the traceparent header of the sending request", data)
+	}
+	if !strings.Contains(string(data), "Subject: Untraced\n\nhi") {
+		t.Fatalf("mail = %q, want no trace headers without a trace", data)
+	}
+}
+
+func TestLogMailerOmitsBody(t *testing.T) {
+	var buf strings.Builder
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	m := NewFileMailer("", "no-reply@workfinder.id")
+	if err := m.Send(context.Background(), Message{To: "budi@example.com", Subject: "Reset your password", Body: "http://localhost:8080/reset?token=secret"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "Subject: Reset your password") || strings.Contains(buf.String(), "secret") {
+		t.Fatalf("log = %q, want the headers without the body", buf.String())
+	}
+}
+
+func TestNewMailerRequiresDriverOutsideDev(t *testing.T) {
+	if _, err := NewMailer("dev", &config.MailConfig{}); err != nil {
+		t.Fatalf("NewMailer() in dev error = %v, want the log driver", err)
+	}
+	if _, err := NewMailer("production", &config.MailConfig{}); err == nil {
+		t.Fatal("NewMailer() in production without a driver error = nil")
+	}
+	if _, err := NewMailer("production", &config.MailConfig{Driver: "log"}); err != nil {
+		t.Fatalf("NewMailer() with the log driver chosen error = %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const startedAtKey = "metrics:started_at"
+
+type gormPlugin struct {
+	metrics *Metrics
+}
+
+// NewGormPlugin observes the duration of every query and exposes the connection pool stats, install it with
+// db.Use.
+func NewGormPlugin(metrics *Metrics) gorm.Plugin {
+	return &gormPlugin{metrics}
+}
+
+func (p *gormPlugin) Name() string {
+	return "metrics"
+}
+
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	if err := p.metrics.registry.Register(collectors.NewDBStatsCollector(sqlDB, "postgres")); err != nil {
+		return err
+	}
+
+	callback := db.Callback()
+
+	for _, err := range []error{
+		callback.Create().Before("gorm:create").Register("metrics:before_create", p.before),
+		callback.Create().After("gorm:create").Register("metrics:after_create", p.after("create")),
+		callback.Query().Before("gorm:query").Register("metrics:before_query", p.before),
+		callback.Query().After("gorm:query").Register("metrics:after_query", p.after("query")),
+		callback.Update().Before("gorm:update").Register("metrics:before_update", p.before),
+		callback.Update().After("gorm:update").Register("metrics:after_update", p.after("update")),
+		callback.Delete().Before("gorm:delete").Register("metrics:before_delete", p.before),
+		callback.Delete().After("gorm:delete").Register("metrics:after_delete", p.after("delete")),
+		callback.Row().Before("gorm:row").Register("metrics:before_row", p.before),
+		callback.Row().After("gorm:row").Register("metrics:after_row", p.after("row")),
+		callback.Raw().Before("gorm:raw").Register("metrics:before_raw", p.before),
+		callback.Raw().After("gorm:raw").Register("metrics:after_raw", p.after("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *gormPlugin) before(db *gorm.DB) {
+	db.InstanceSet(startedAtKey, time.Now())
+}
+
+func (p *gormPlugin) after(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startedAtKey)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+
+		p.metrics.DBQueryDuration.WithLabelValues(operation, table).Observe(time.Since(value.(time.Time)).Seconds())
+	}
+}
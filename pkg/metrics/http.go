@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware observes every request under the route template it matched, e.g. /api/v1/jobs/:id, so the
+// route label stays bounded no matter which ids are requested.
+func (m *Metrics) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+			if err != nil {
+				// the status is only known once the error handler wrote the response, it won't write it twice
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			m.HTTPRequestDuration.
+				WithLabelValues(route, c.Request().Method, strconv.Itoa(c.Response().Status)).
+				Observe(time.Since(start).Seconds())
+
+			return err
+		}
+	}
+}
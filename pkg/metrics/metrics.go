@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "workfinder"
+
+// Metrics holds every collector of the application, all of them registered on one registry so tests can
+// build their own instead of sharing the global one.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	HTTPRequestDuration *prometheus.HistogramVec
+	DBQueryDuration     *prometheus.HistogramVec
+	CacheRequests       *prometheus.CounterVec
+
+	JobsPosted            prometheus.Counter
+	ApplicationsSubmitted prometheus.Counter
+	ApplicationsApproved  prometheus.Counter
+}
+
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		registry: registry,
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by route template, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		DBQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Duration of database queries by operation and table.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		CacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      "Cache reads by cache and result, one of hit, miss or error.",
+		}, []string{"cache", "result"}),
+		JobsPosted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "jobs_posted_total",
+			Help:      "Jobs posted by clients.",
+		}),
+		ApplicationsSubmitted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "applications_submitted_total",
+			Help:      "Job applications submitted, including reapplications.",
+		}),
+		ApplicationsApproved: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "applications_approved_total",
+			Help:      "Applicants hired by job owners.",
+		}),
+	}
+
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequestDuration,
+		m.DBQueryDuration,
+		m.CacheRequests,
+		m.JobsPosted,
+		m.ApplicationsSubmitted,
+		m.ApplicationsApproved,
+	)
+
+	return m
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMiddlewareLabelsRouteTemplate(t *testing.T) {
+	m := NewMetrics(prometheus.NewRegistry())
+
+	e := echo.New()
+	e.Use(m.Middleware())
+	e.GET("/api/v1/jobs/:id", func(c echo.Context) error {
+		if c.Param("id") == "missing" {
+			return echo.ErrNotFound
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/api/v1/jobs/1", "/api/v1/jobs/2", "/api/v1/jobs/missing", "/nowhere"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	tests := []struct {
+		route  string
+		status string
+		want   int
+	}{
+		{route: "/api/v1/jobs/:id", status: "200", want: 2},
+		{route: "/api/v1/jobs/:id", status: "404", want: 1},
+		{route: "unmatched", status: "404", want: 1},
+	}
+
+	for _, tt := range tests {
+		histogram := m.HTTPRequestDuration.WithLabelValues(tt.route, http.MethodGet, tt.status).(prometheus.Histogram)
+		if count := sampleCount(t, histogram); count != tt.want {
+			t.Fatalf("requests of %s with status %s = %d, want %d", tt.route, tt.status, count, tt.want)
+		}
+	}
+}
+
+func TestGormPluginObservesQueries(t *testing.T) {
+	m := NewMetrics(prometheus.NewRegistry())
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	defer sqlDB.Close()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	if err := db.Use(NewGormPlugin(m)); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "jobs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "jobs"`).WillReturnError(errors.New("connection reset"))
+
+	var rows []map[string]interface{}
+	db.Table("jobs").Find(&rows)
+	db.Table("jobs").Find(&rows)
+
+	histogram := m.DBQueryDuration.WithLabelValues("query", "jobs").(prometheus.Histogram)
+	if count := sampleCount(t, histogram); count != 2 {
+		t.Fatalf("observed queries = %d, want failed queries observed too", count)
+	}
+
+	if count, err := testutil.GatherAndCount(m.registry, "go_sql_open_connections"); err != nil || count != 1 {
+		t.Fatalf("pool stats = %d, %v, want the connection pool registered", count, err)
+	}
+}
+
+func sampleCount(t *testing.T, histogram prometheus.Histogram) int {
+	t.Helper()
+
+	metric := &dto.Metric{}
+	if err := histogram.Write(metric); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	return int(metric.GetHistogram().GetSampleCount())
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// SchemaDrift is a difference between a GORM model and the table it is stored in.
+type SchemaDrift struct {
+	Table   string
+	Column  string
+	Problem string
+}
+
+func (d SchemaDrift) String() string {
+	return fmt.Sprintf("%s.%s: %s", d.Table, d.Column, d.Problem)
+}
+
+// Column is a column of a live table as reported by information_schema.
+type Column struct {
+	Name       string
+	Nullable   bool
+	UDTName    string
+	HasDefault bool
+}
+
+// CheckSchemaDrift compares every model with its table in the current schema of db. Drifts are ordered by
+// table and column.
+func CheckSchemaDrift(db *gorm.DB, models ...interface{}) ([]SchemaDrift, error) {
+	drifts := make([]SchemaDrift, 0)
+	cacheStore := &sync.Map{}
+
+	for _, model := range models {
+		s, err := schema.Parse(model, cacheStore, db.NamingStrategy)
+		if err != nil {
+			return nil, err
+		}
+
+		columns := make([]Column, 0)
+
+		if err := db.Raw(`SELECT column_name AS name, is_nullable = 'YES' AS nullable, udt_name, column_default IS NOT NULL AS has_default
+			FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = ?`, s.Table).
+			Scan(&columns).Error; err != nil {
+			return nil, err
+		}
+
+		drifts = append(drifts, CompareSchema(s, columns)...)
+	}
+
+	sort.SliceStable(drifts, func(i, j int) bool {
+		if drifts[i].Table != drifts[j].Table {
+			return drifts[i].Table < drifts[j].Table
+		}
+		return drifts[i].Column < drifts[j].Column
+	})
+
+	return drifts, nil
+}
+
+// CompareSchema reports columns the model writes but the table lacks, NOT NULL columns without a default the
+// model never writes, so every insert fails, nullable fields stored in NOT NULL columns, and Go types that
+// can't hold the column type.
+//
+// A nullable column behind a plain field isn't reported, GORM reads NULL as the zero value.
+func CompareSchema(s *schema.Schema, columns []Column) []SchemaDrift {
+	if len(columns) == 0 {
+		return []SchemaDrift{{Table: s.Table, Column: "*", Problem: "table doesn't exist"}}
+	}
+
+	drifts := make([]SchemaDrift, 0)
+	byName := make(map[string]Column, len(columns))
+
+	for _, column := range columns {
+		byName[column.Name] = column
+	}
+
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+
+		column, ok := byName[field.DBName]
+		if !ok {
+			drifts = append(drifts, SchemaDrift{s.Table, field.DBName, fmt.Sprintf("column is missing, %s.%s writes it", s.Name, field.Name)})
+			continue
+		}
+
+		if isNullableField(field) && !column.Nullable {
+			drifts = append(drifts, SchemaDrift{s.Table, field.DBName, fmt.Sprintf("column is NOT NULL but %s.%s can be nil", s.Name, field.Name)})
+		}
+
+		if accepted := acceptedColumnTypes(field); accepted != nil && !contains(accepted, column.UDTName) {
+			drifts = append(drifts, SchemaDrift{s.Table, field.DBName, fmt.Sprintf("column is %s but %s.%s is %s", column.UDTName, s.Name, field.Name, field.FieldType)})
+		}
+	}
+
+	for _, column := range columns {
+		if _, ok := s.FieldsByDBName[column.Name]; !ok && !column.Nullable && !column.HasDefault {
+			drifts = append(drifts, SchemaDrift{s.Table, column.Name, fmt.Sprintf("column is NOT NULL without a default but %s has no field for it", s.Name)})
+		}
+	}
+
+	return drifts
+}
+
+var (
+	uuidType      = reflect.TypeOf(uuid.UUID{})
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+	textTypes     = []string{"text", "varchar", "bpchar", "citext"}
+)
+
+func isNullableField(field *schema.Field) bool {
+	if field.FieldType.Kind() == reflect.Ptr || field.FieldType == deletedAtType {
+		return true
+	}
+
+	return field.FieldType.PkgPath() == "database/sql" && strings.HasPrefix(field.FieldType.Name(), "Null")
+}
+
+// acceptedColumnTypes lists the udt_names a field can be stored in, nil when the type isn't checked.
+func acceptedColumnTypes(field *schema.Field) []string {
+	if field.Serializer != nil {
+		return textTypes
+	}
+
+	switch field.IndirectFieldType {
+	case uuidType:
+		return []string{"uuid"}
+	case timeType, deletedAtType:
+		return []string{"timestamptz", "timestamp", "date"}
+	}
+
+	switch field.IndirectFieldType.Kind() {
+	case reflect.String:
+		return textTypes
+	case reflect.Bool:
+		return []string{"bool"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{"int2", "int4", "int8"}
+	case reflect.Float32, reflect.Float64:
+		return []string{"float4", "float8", "numeric"}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
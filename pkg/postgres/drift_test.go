@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type driftUser struct {
+	ID         uuid.UUID
+	Name       string
+	Nickname   string
+	Age        int
+	VerifiedAt *time.Time
+	DeletedAt  gorm.DeletedAt
+}
+
+func (driftUser) TableName() string {
+	return "users"
+}
+
+func TestCompareSchema(t *testing.T) {
+	s, err := schema.Parse(&driftUser{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("schema.Parse() error = %v", err)
+	}
+
+	columns := []Column{
+		{Name: "id", UDTName: "uuid", HasDefault: true},
+		{Name: "name", UDTName: "varchar"},
+		// nickname is missing
+		{Name: "age", UDTName: "text", Nullable: true},
+		{Name: "verified_at", UDTName: "timestamptz"},
+		{Name: "deleted_at", UDTName: "timestamptz", Nullable: true},
+		// like users.role before the entity had a Role field, every insert fails
+		{Name: "role", UDTName: "varchar"},
+		{Name: "bio", UDTName: "text", Nullable: true},
+		{Name: "created_at", UDTName: "timestamptz", HasDefault: true},
+	}
+
+	got := CompareSchema(s, columns)
+
+	want := []SchemaDrift{
+		{"users", "nickname", "column is missing, driftUser.Nickname writes it"},
+		{"users", "age", "column is text but driftUser.Age is int"},
+		{"users", "verified_at", "column is NOT NULL but driftUser.VerifiedAt can be nil"},
+		{"users", "role", "column is NOT NULL without a default but driftUser has no field for it"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("CompareSchema() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestCompareSchemaMissingTable(t *testing.T) {
+	s, _ := schema.Parse(&driftUser{}, &sync.Map{}, schema.NamingStrategy{})
+
+	got := CompareSchema(s, nil)
+	if len(got) != 1 || got[0].Problem != "table doesn't exist" {
+		t.Fatalf("CompareSchema() without columns = %v, want a missing table", got)
+	}
+}
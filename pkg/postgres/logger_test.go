@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestGormLoggerOmitsParameters(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	defer sqlDB.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: NewGormLogger(logger)})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	var rows []map[string]interface{}
+	db.Table("users").Where("password = ?", "hunter2").Find(&rows)
+
+	if !strings.Contains(buf.String(), `password = $1`) {
+		t.Fatalf("log = %s, want the statement logged", buf.String())
+	}
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Fatalf("log = %s, want the parameters left out", buf.String())
+	}
+}
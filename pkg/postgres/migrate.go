@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+
+	"github.com/DavidAfdal/workfinder/config"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// Migrator applies SQL migrations. Every command holds a Postgres advisory lock while it runs, so replicas
+// migrating on startup wait for each other instead of racing.
+type Migrator interface {
+	Up() error
+	Down(steps int) error
+	Force(version uint) error
+	Status() (*MigrationStatus, error)
+	Close() error
+}
+
+// MigrationStatus compares the database with the known migrations. Gaps are versions missing from the
+// numbering, e.g. 000004 was never added, they are skipped and don't need to be applied.
+type MigrationStatus struct {
+	Version uint
+	Dirty   bool
+	Applied []uint
+	Pending []uint
+	Gaps    []uint
+}
+
+type migrator struct {
+	migrate  *migrate.Migrate
+	versions []uint
+}
+
+// NewMigrator reads the migrations from migrations and records the applied version in schema_migrations,
+// the table the migrate CLI used so far.
+func NewMigrator(config *config.PostgresConfig, migrations fs.FS) (Migrator, error) {
+	versions, err := MigrationVersions(migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := iofs.New(migrations, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	dsn := url.URL{
+		Scheme:   "pgx5",
+		User:     url.UserPassword(config.User, config.Password),
+		Host:     fmt.Sprintf("%s:%s", config.Host, config.Port),
+		Path:     config.Database,
+		RawQuery: url.Values{"sslmode": {config.SSLMode}}.Encode(),
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, dsn.String())
+	if err != nil {
+		return nil, err
+	}
+
+	m.Log = migrateLogger{}
+	m.LockTimeout = config.MigrateLockTimeout
+
+	return &migrator{migrate: m, versions: versions}, nil
+}
+
+func (m *migrator) Up() error {
+	return readable(ignoreNoChange(m.migrate.Up()))
+}
+
+func (m *migrator) Down(steps int) error {
+	if steps <= 0 {
+		return errors.New("down needs a positive number of steps")
+	}
+
+	return readable(ignoreNoChange(m.migrate.Steps(-steps)))
+}
+
+// Force sets the version without running anything, it is how a dirty database is recovered once it was fixed
+// by hand. 0 marks the database as not migrated at all.
+func (m *migrator) Force(version uint) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("migration %06d doesn't exist", version)
+	}
+
+	if version == 0 {
+		return m.migrate.Force(-1)
+	}
+
+	return m.migrate.Force(int(version))
+}
+
+func (m *migrator) Status() (*MigrationStatus, error) {
+	status := &MigrationStatus{Applied: make([]uint, 0), Pending: make([]uint, 0), Gaps: gaps(m.versions)}
+
+	version, dirty, err := m.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, err
+	}
+
+	status.Version, status.Dirty = version, dirty
+
+	for _, v := range m.versions {
+		if v <= version {
+			status.Applied = append(status.Applied, v)
+		} else {
+			status.Pending = append(status.Pending, v)
+		}
+	}
+
+	return status, nil
+}
+
+func (m *migrator) Close() error {
+	sourceErr, databaseErr := m.migrate.Close()
+
+	return errors.Join(sourceErr, databaseErr)
+}
+
+// readable explains the errors migrate returns when the database is dirty or at a version that has no file,
+// e.g. it was migrated from another branch. Both are only detected under the advisory lock, checking the
+// version before it would fail while another replica is in the middle of migrating.
+func readable(err error) error {
+	var dirty migrate.ErrDirty
+
+	switch {
+	case errors.As(err, &dirty):
+		return fmt.Errorf("migration %06d failed halfway, fix the database and run migrate force with the last clean version", dirty.Version)
+	case errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("database is at a migration which doesn't exist, run migrate force with the version it matches: %w", err)
+	default:
+		return err
+	}
+}
+
+func (m *migrator) known(version uint) bool {
+	i := sort.Search(len(m.versions), func(i int) bool { return m.versions[i] >= version })
+
+	return i < len(m.versions) && m.versions[i] == version
+}
+
+// MigrationVersions lists the versions found in migrations in order, every version needs an up and a down file.
+func MigrationVersions(migrations fs.FS) ([]uint, error) {
+	entries, err := fs.ReadDir(migrations, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	directions := make(map[uint]map[source.Direction]bool)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		parsed, err := source.Parse(entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+
+		if directions[parsed.Version] == nil {
+			directions[parsed.Version] = make(map[source.Direction]bool)
+		}
+		directions[parsed.Version][parsed.Direction] = true
+	}
+
+	versions := make([]uint, 0, len(directions))
+
+	for version, found := range directions {
+		if !found[source.Up] || !found[source.Down] {
+			return nil, fmt.Errorf("migration %06d needs both an up and a down file", version)
+		}
+		versions = append(versions, version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	return versions, nil
+}
+
+func gaps(versions []uint) []uint {
+	missing := make([]uint, 0)
+
+	for i := 1; i < len(versions); i++ {
+		for v := versions[i-1] + 1; v < versions[i]; v++ {
+			missing = append(missing, v)
+		}
+	}
+
+	return missing
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+
+	return err
+}
+
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...interface{}) {
+	log.Printf("migrate: "+format, v...)
+}
+
+func (migrateLogger) Verbose() bool {
+	return true
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+func TestMigrationVersions(t *testing.T) {
+	migrations := fstest.MapFS{
+		"000001_create_user_table.up.sql":       {},
+		"000001_create_user_table.down.sql":     {},
+		"000003_create_job_table.up.sql":        {},
+		"000003_create_job_table.down.sql":      {},
+		"000002_create_category_table.up.sql":   {},
+		"000002_create_category_table.down.sql": {},
+		"000006_add_search.up.sql":              {},
+		"000006_add_search.down.sql":            {},
+	}
+
+	versions, err := MigrationVersions(migrations)
+	if err != nil {
+		t.Fatalf("MigrationVersions() error = %v", err)
+	}
+
+	if want := []uint{1, 2, 3, 6}; !reflect.DeepEqual(versions, want) {
+		t.Fatalf("MigrationVersions() = %v, want %v", versions, want)
+	}
+
+	if got, want := gaps(versions), []uint{4, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("gaps() = %v, want %v", got, want)
+	}
+
+	m := &migrator{versions: versions}
+	if m.known(4) || !m.known(6) {
+		t.Fatal("known() doesn't match the migration files")
+	}
+}
+
+func TestMigrationVersionsRequiresBothDirections(t *testing.T) {
+	migrations := fstest.MapFS{
+		"000001_create_user_table.up.sql":     {},
+		"000001_create_user_table.down.sql":   {},
+		"000002_create_category_table.up.sql": {},
+	}
+
+	if _, err := MigrationVersions(migrations); err == nil {
+		t.Fatal("MigrationVersions() without a down file error = nil")
+	}
+
+	if _, err := MigrationVersions(fstest.MapFS{"create_user_table.sql": {}}); err == nil {
+		t.Fatal("MigrationVersions() with a badly named file error = nil")
+	}
+}
+
+func TestReadableMigrateErrors(t *testing.T) {
+	if err := readable(migrate.ErrDirty{Version: 13}); err == nil || !strings.Contains(err.Error(), "migration 000013 failed halfway") {
+		t.Fatalf("readable(ErrDirty) = %v, want the dirty version and how to recover", err)
+	}
+
+	missing := fmt.Errorf("no migration found for version 99: %w", os.ErrNotExist)
+	if err := readable(missing); err == nil || !strings.Contains(err.Error(), "run migrate force") || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("readable(missing version) = %v, want how to recover", err)
+	}
+
+	if err := readable(nil); err != nil {
+		t.Fatalf("readable(nil) = %v", err)
+	}
+}
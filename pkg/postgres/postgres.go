@@ -2,18 +2,18 @@ package postgres
 
 import (
 	"fmt"
+	"log/slog"
 
 	"github.com/DavidAfdal/workfinder/config"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-func InitPostgres(config *config.PostgresConfig) (*gorm.DB, error) {
-   dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", config.Host, config.Port, config.User, config.Password, config.Database)
+func InitPostgres(config *config.PostgresConfig, logger *slog.Logger) (*gorm.DB, error) {
+   dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", config.Host, config.Port, config.User, config.Password, config.Database, config.SSLMode)
 
    db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-	  Logger: logger.Default.LogMode(logger.Info),
+	  Logger: NewGormLogger(logger),
    })
    if err != nil {
 	return db, err
@@ -7,6 +7,22 @@ type Response struct {
 type Meta struct {
 	Code int `json:"code"`
 	Message string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Pagination struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Page       int    `json:"page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
 }
 
 func SuccessResponse(code int, message string, data interface{}) Response {
@@ -19,6 +35,17 @@ func SuccessResponse(code int, message string, data interface{}) Response {
 	}
 }
 
+func PaginatedResponse(code int, message string, data interface{}, pagination Pagination) Response {
+	return Response{
+		Meta: Meta{
+			Code: code,
+			Message: message,
+			Pagination: &pagination,
+		},
+		Data: data,
+	}
+}
+
 func ErrorResponse(code int, message string) Response {
 	return Response{
 		Meta: Meta{
@@ -28,3 +55,17 @@ func ErrorResponse(code int, message string) Response {
 		Data: nil,
 	}
 }
+
+// ErrorCodeResponse adds a stable machine readable errorCode next to the human readable message,
+// fieldErrors lists the rejected request fields of a validation error.
+func ErrorCodeResponse(code int, errorCode string, message string, fieldErrors ...FieldError) Response {
+	return Response{
+		Meta: Meta{
+			Code: code,
+			Message: message,
+			ErrorCode: errorCode,
+			Errors: fieldErrors,
+		},
+		Data: nil,
+	}
+}
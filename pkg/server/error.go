@@ -0,0 +1,81 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+	"github.com/DavidAfdal/workfinder/pkg/response"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var (
+	errUnauthorized   = apperror.Unauthorized("unauthorized", "anda harus login untuk mengakses resource ini")
+	errSessionExpired = apperror.Unauthorized("session_expired", "sesi anda telah berakhir, silakan login kembali")
+	errForbidden      = apperror.Forbidden("forbidden", "anda tidak memiliki akses ke resource ini")
+)
+
+var kindStatus = map[apperror.Kind]int{
+	apperror.KindValidation:   http.StatusBadRequest,
+	apperror.KindUnauthorized: http.StatusUnauthorized,
+	apperror.KindForbidden:    http.StatusForbidden,
+	apperror.KindNotFound:     http.StatusNotFound,
+	apperror.KindConflict:     http.StatusConflict,
+	apperror.KindInternal:     http.StatusInternalServerError,
+}
+
+// HTTPErrorHandler writes every error returned by a handler or middleware as a response.ErrorCodeResponse.
+// Only domain errors and echo's own errors reach the client as is, anything else is logged and answered
+// with a generic 500 so database messages never leak.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status, errorCode, message := http.StatusInternalServerError, "internal_error", "internal server error"
+	var fieldErrors []response.FieldError
+
+	var httpErr *echo.HTTPError
+
+	if appErr, ok := apperror.As(err); ok {
+		status, errorCode, message = kindStatus[appErr.Kind], appErr.Code, appErr.Message
+		for _, field := range appErr.Fields {
+			fieldErrors = append(fieldErrors, response.FieldError{Field: field.Field, Message: field.Message})
+		}
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		status, errorCode, message = http.StatusNotFound, "not_found", "resource not found"
+	} else if errors.As(err, &httpErr) {
+		status, errorCode = httpErr.Code, statusErrorCode(httpErr.Code)
+		if msg, ok := httpErr.Message.(string); ok {
+			message = msg
+		} else {
+			message = http.StatusText(httpErr.Code)
+		}
+	}
+
+	if status >= http.StatusInternalServerError {
+		slog.ErrorContext(c.Request().Context(), "request failed", "error", err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, response.ErrorCodeResponse(status, errorCode, message, fieldErrors...))
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to write error response", "error", err)
+	}
+}
+
+// statusErrorCode turns a status code into an error code, 404 becomes not_found.
+func statusErrorCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "unknown_error"
+	}
+	return strings.ToLower(strings.ReplaceAll(text, " ", "_"))
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+	"github.com/DavidAfdal/workfinder/pkg/response"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{name: "validation", err: apperror.Validation("invalid_id", "invalid id"), wantStatus: http.StatusBadRequest, wantCode: "invalid_id", wantMessage: "invalid id"},
+		{name: "wrapped conflict", err: fmt.Errorf("apply: %w", apperror.Conflict("already_applied", "already applied")), wantStatus: http.StatusConflict, wantCode: "already_applied", wantMessage: "already applied"},
+		{name: "cause stays private", err: apperror.Forbidden("forbidden", "forbidden").Wrap(errors.New("pq: secret")), wantStatus: http.StatusForbidden, wantCode: "forbidden", wantMessage: "forbidden"},
+		{name: "record not found", err: gorm.ErrRecordNotFound, wantStatus: http.StatusNotFound, wantCode: "not_found", wantMessage: "resource not found"},
+		{name: "echo error", err: echo.ErrMethodNotAllowed, wantStatus: http.StatusMethodNotAllowed, wantCode: "method_not_allowed", wantMessage: "Method Not Allowed"},
+		{name: "unknown error", err: errors.New(`pq: duplicate key value violates unique constraint "users_email_key"`), wantStatus: http.StatusInternalServerError, wantCode: "internal_error", wantMessage: "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+			HTTPErrorHandler(tt.err, c)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			var body response.Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid response body: %v", err)
+			}
+			if body.Meta.ErrorCode != tt.wantCode || body.Meta.Message != tt.wantMessage {
+				t.Fatalf("meta = %+v, want error_code %q and message %q", body.Meta, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/DavidAfdal/workfinder/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// validRequestID keeps a client supplied X-Request-ID from injecting arbitrary text into the logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestID reuses a valid X-Request-ID from the client or generates one, echoes it in the response and puts
+// it in the request context, where the logger picks it up.
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id := c.Request().Header.Get(echo.HeaderXRequestID)
+			if !validRequestID.MatchString(id) {
+				id = uuid.NewString()
+			}
+
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+			c.SetRequest(c.Request().WithContext(logger.WithRequestID(c.Request().Context(), id)))
+
+			return next(c)
+		}
+	}
+}
+
+// RequestLogger logs one line per request. Only the path is logged, query strings may carry tokens.
+func RequestLogger() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			req, res := c.Request(), c.Response()
+
+			level := slog.LevelInfo
+			if res.Status >= 500 {
+				level = slog.LevelError
+			}
+
+			slog.LogAttrs(req.Context(), level, "request",
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.String("route", c.Path()),
+				slog.Int("status", res.Status),
+				slog.Duration("latency", time.Since(start)),
+				slog.Int64("bytes_out", res.Size),
+				slog.String("remote_ip", c.RealIP()),
+			)
+
+			return err
+		}
+	}
+}
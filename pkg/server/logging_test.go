@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DavidAfdal/workfinder/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{name: "reused", incoming: "3f2b9c1e-upstream", wantSame: true},
+		{name: "generated", incoming: ""},
+		{name: "invalid replaced", incoming: "evil\" injected=true"},
+		{name: "too long replaced", incoming: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(RequestID())
+
+			var seen string
+			e.GET("/", func(c echo.Context) error {
+				seen = logger.RequestID(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderXRequestID, tt.incoming)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(echo.HeaderXRequestID)
+			if id == "" || id != seen {
+				t.Fatalf("response id = %q, context id = %q, want the same non-empty id", id, seen)
+			}
+			if (id == tt.incoming) != tt.wantSame {
+				t.Fatalf("id = %q for incoming %q, want reused = %v", id, tt.incoming, tt.wantSame)
+			}
+		})
+	}
+}
@@ -2,16 +2,22 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/DavidAfdal/workfinder/config"
+	"github.com/DavidAfdal/workfinder/pkg/health"
+	"github.com/DavidAfdal/workfinder/pkg/metrics"
 	"github.com/DavidAfdal/workfinder/pkg/response"
 	"github.com/DavidAfdal/workfinder/pkg/route"
 	"github.com/DavidAfdal/workfinder/pkg/token"
+	"github.com/DavidAfdal/workfinder/pkg/validator"
 	"github.com/golang-jwt/jwt/v5"
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
@@ -21,20 +27,63 @@ import (
 
 type Server struct {
 	*echo.Echo
+	config  *config.ServerConfig
+	checker health.Checker
 }
 
-func NewServer(serverName string, publicRoutes, privateRoutes []*route.Route, secretKey string) *Server {
+func NewServer(serverName string, config *config.ServerConfig, publicRoutes, privateRoutes []*route.Route, keySet token.KeySet, denylist token.Denylist, checker health.Checker, metrics *metrics.Metrics) *Server {
 	e := echo.New()
-
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Validator = validator.NewValidator()
+	e.HideBanner = true
+	e.HidePort = true
+
+	// StartTLS serves on TLSServer rather than Server, both get the timeouts
+	for _, server := range []*http.Server{e.Server, e.TLSServer} {
+		server.ReadTimeout = config.ReadTimeout
+		server.ReadHeaderTimeout = config.ReadHeaderTimeout
+		server.WriteTimeout = config.WriteTimeout
+		server.IdleTimeout = config.IdleTimeout
+	}
 
 	e.Use(
-		middleware.Logger(),
+		RequestID(),
+		Tracing(),
+		metrics.Middleware(),
+		RequestLogger(),
 		middleware.CORS(),
+		middleware.BodyLimit(config.BodyLimit),
 	)
+
+	// a handler running past RequestTimeout gets its database and Redis calls cancelled and answers 503
+	if config.RequestTimeout > 0 {
+		e.Use(middleware.ContextTimeout(config.RequestTimeout))
+	}
+
 	e.GET("/", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "Welcome to WorkFinder API", nil))
 	})
 
+	// liveness only tells the process is serving, a dependency outage shouldn't get the instance restarted
+	e.GET("/healthz", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "alive", nil))
+	})
+
+	e.GET("/readyz", func(c echo.Context) error {
+		report, ready := checker.Ready(c.Request().Context())
+		if !ready {
+			return c.JSON(http.StatusServiceUnavailable, response.SuccessResponse(http.StatusServiceUnavailable, "not ready", report))
+		}
+		return c.JSON(http.StatusOK, response.SuccessResponse(http.StatusOK, "ready", report))
+	})
+
+	e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+
+	e.GET("/.well-known/jwks.json", func(c echo.Context) error {
+		c.Response().Header().Set("Cache-Control", "public, max-age=300")
+		return c.JSON(http.StatusOK, keySet.JWKS())
+	})
+
 	v1 := e.Group(fmt.Sprintf("/%s/v1", serverName))
 
 	if len(publicRoutes) > 0 {
@@ -44,56 +93,122 @@ func NewServer(serverName string, publicRoutes, privateRoutes []*route.Route, se
 	}
 	if len(privateRoutes) > 0 {
 		for _, route := range privateRoutes {
-			v1.Add(route.Methode, route.Path, route.Handler, JWTProtection(secretKey))
+			middlewares := []echo.MiddlewareFunc{JWTProtection(keySet, denylist)}
+			if len(route.Roles) > 0 {
+				middlewares = append(middlewares, RoleAuthorization(route.Roles...))
+			}
+			v1.Add(route.Methode, route.Path, route.Handler, middlewares...)
 		}
 	}
 
-	fmt.Println("server running on port 8080")
-
-	return &Server{e}
+	return &Server{e, config, checker}
 }
 
+// Run serves until SIGINT or SIGTERM, then stops accepting connections and waits for in-flight requests
+// for at most ShutdownTimeout before returning.
+func (srv *Server) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-func (srv *Server) Run()  {
-	runServer(srv)
-	gracefulShutdown(srv)
+	return srv.serve(ctx)
 }
 
-func runServer(srv *Server) {
+func (srv *Server) serve(ctx context.Context) error {
+	errs := make(chan error, 1)
+
 	go func() {
-		err := srv.Start(":8080")
-		log.Fatal(err)
+		errs <- srv.start()
 	}()
-}
 
-func gracefulShutdown(srv *Server) {
-	quit := make(chan os.Signal, 1)
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
 
-	signal.Notify(quit, os.Interrupt)
+	srv.checker.Drain()
+	slog.Info("shutting down, failing readiness", "delay", srv.config.ShutdownDelay)
+	time.Sleep(srv.config.ShutdownDelay)
 
-	<-quit
+	slog.Info("draining in-flight requests", "timeout", srv.config.ShutdownTimeout)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), srv.config.ShutdownTimeout)
 	defer cancel()
 
-	go func() {
-		if err := srv.Shutdown(ctx); err != nil {
-			srv.Logger.Fatal("Server Shutdown:", err)
-		}
-	}()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-errs; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
 }
 
-func JWTProtection(secretKey string) echo.MiddlewareFunc {
-	return echojwt.WithConfig(echojwt.Config{
+func (srv *Server) start() error {
+	address := ":" + srv.config.Port
+
+	if (srv.config.TLSCertFile == "") != (srv.config.TLSKeyFile == "") {
+		return errors.New("TLS needs both SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE, only one is set")
+	}
+
+	if srv.config.TLSCertFile != "" {
+		slog.Info("server running", "port", srv.config.Port, "tls", true)
+		return srv.StartTLS(address, srv.config.TLSCertFile, srv.config.TLSKeyFile)
+	}
+
+	slog.Info("server running", "port", srv.config.Port, "tls", false)
+
+	return srv.Start(address)
+}
+
+func JWTProtection(keySet token.KeySet, denylist token.Denylist) echo.MiddlewareFunc {
+	jwtMiddleware := echojwt.WithConfig(echojwt.Config{
 		NewClaimsFunc: func(c echo.Context) jwt.Claims {
 			return new(token.JwtCustomClaims)
 		},
-		SigningKey: []byte(secretKey),
+		KeyFunc: keySet.Keyfunc,
 		ErrorHandler: func(c echo.Context, err error) error {
-			return c.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "anda harus login untuk mengakses resource ini"))
+			return errUnauthorized.Wrap(err)
 		},
 	})
-}
 
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return jwtMiddleware(func(c echo.Context) error {
+			user, _ := c.Get("user").(*jwt.Token)
+			claims, ok := user.Claims.(*token.JwtCustomClaims)
 
+			if !ok || denylist.Contains(c.Request().Context(), claims.RegisteredClaims.ID) {
+				return errSessionExpired
+			}
 
+			return next(c)
+		})
+	}
+}
+
+// RoleAuthorization only lets the request through when the role in the JWT claims is one of roles, it must run after JWTProtection.
+func RoleAuthorization(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, ok := c.Get("user").(*jwt.Token)
+			if !ok {
+				return errUnauthorized
+			}
+
+			claims, ok := user.Claims.(*token.JwtCustomClaims)
+			if !ok {
+				return errUnauthorized
+			}
+
+			for _, role := range roles {
+				if claims.Role == role {
+					return next(c)
+				}
+			}
+
+			return errForbidden
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DavidAfdal/workfinder/config"
+	"github.com/DavidAfdal/workfinder/pkg/cache"
+	"github.com/DavidAfdal/workfinder/pkg/health"
+	"github.com/DavidAfdal/workfinder/pkg/metrics"
+	"github.com/DavidAfdal/workfinder/pkg/route"
+	"github.com/DavidAfdal/workfinder/pkg/token"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newTestServer(t *testing.T, routes ...*route.Route) *Server {
+	t.Helper()
+
+	cfg := &config.ServerConfig{
+		Port:            "0",
+		ReadTimeout:     time.Second,
+		WriteTimeout:    5 * time.Second,
+		ShutdownTimeout: 5 * time.Second,
+		RequestTimeout:  time.Second,
+		BodyLimit:       "1K",
+	}
+
+	return NewServer("api", cfg, routes, nil, token.NewHMACKeySet("test-secret"), token.NewDenylist(cache.NewMemoryCacheable(10)), health.NewChecker(time.Second), metrics.NewMetrics(prometheus.NewRegistry()))
+}
+
+// startTestServer serves srv until the returned cancel is called, serve's result is sent on the channel.
+func startTestServer(t *testing.T, srv *Server) (string, context.CancelFunc, <-chan error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- srv.serve(ctx)
+	}()
+
+	for i := 0; i < 100; i++ {
+		if addr := srv.ListenerAddr(); addr != nil {
+			return "http://" + addr.String(), cancel, done
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	t.Fatal("server didn't start listening")
+	return "", nil, nil
+}
+
+func TestServerDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	slow := &route.Route{Methode: http.MethodGet, Path: "/slow", Handler: func(c echo.Context) error {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		return c.String(http.StatusOK, "done")
+	}}
+
+	srv := newTestServer(t, slow)
+	url, cancel, done := startTestServer(t, srv)
+	defer cancel()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+
+	go func() {
+		res, err := http.Get(url + "/api/v1/slow")
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		responses <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatalf("serve() error = %v", err)
+	}
+
+	// serve only returns once the in-flight request has been answered
+	select {
+	case res := <-responses:
+		if res.err != nil || res.body != "done" {
+			t.Fatalf("in-flight request = %q, %v, want it to complete", res.body, res.err)
+		}
+	default:
+		t.Fatal("serve() returned before the in-flight request completed")
+	}
+
+	if _, err := http.Get(url + "/"); err == nil {
+		t.Fatal("server still accepts requests after shutdown")
+	}
+}
+
+func TestServerBodyLimit(t *testing.T) {
+	echoBody := &route.Route{Methode: http.MethodPost, Path: "/echo", Handler: func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, string(body))
+	}}
+
+	srv := newTestServer(t, echoBody)
+	url, cancel, done := startTestServer(t, srv)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	tests := []struct {
+		name       string
+		size       int
+		wantStatus int
+	}{
+		{name: "within limit", size: 512, wantStatus: http.StatusOK},
+		{name: "over limit", size: 2048, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := http.Post(url+"/api/v1/echo", "text/plain", strings.NewReader(strings.Repeat("a", tt.size)))
+			if err != nil {
+				t.Fatalf("POST error = %v", err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", res.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestServerFailsReadinessBeforeShutdown(t *testing.T) {
+	srv := newTestServer(t)
+	srv.config.ShutdownDelay = 300 * time.Millisecond
+	url, cancel, done := startTestServer(t, srv)
+	defer cancel()
+
+	readyz := func() int {
+		res, err := http.Get(url + "/readyz")
+		if err != nil {
+			t.Fatalf("GET /readyz error = %v", err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	if status := readyz(); status != http.StatusOK {
+		t.Fatalf("/readyz = %d before shutdown, want 200", status)
+	}
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+
+	// still accepting connections, but telling load balancers to go away
+	if status := readyz(); status != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz = %d while draining, want 503", status)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("serve() error = %v", err)
+	}
+}
+
+func TestServerRequestTimeout(t *testing.T) {
+	blocked := &route.Route{Methode: http.MethodGet, Path: "/blocked", Handler: func(c echo.Context) error {
+		// stands in for a query that only returns once its context is done
+		<-c.Request().Context().Done()
+		return c.Request().Context().Err()
+	}}
+
+	srv := newTestServer(t, blocked)
+	url, cancel, done := startTestServer(t, srv)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	res, err := http.Get(url + "/api/v1/blocked")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503 once the request deadline passes", res.StatusCode)
+	}
+}
+
+func TestServerTLSConfig(t *testing.T) {
+	srv := newTestServer(t)
+
+	// StartTLS serves on TLSServer, it needs the timeouts as much as Server
+	if srv.TLSServer.ReadTimeout != time.Second || srv.TLSServer.WriteTimeout != 5*time.Second {
+		t.Fatalf("TLSServer timeouts = %s, %s, want the configured ones", srv.TLSServer.ReadTimeout, srv.TLSServer.WriteTimeout)
+	}
+
+	srv.config.TLSCertFile = "cert.pem"
+	if err := srv.start(); err == nil || !strings.Contains(err.Error(), "SERVER_TLS_KEY_FILE") {
+		t.Fatalf("start() with only a certificate error = %v, want it to refuse plain HTTP", err)
+	}
+}
+
+func TestRoleAuthorization(t *testing.T) {
+	keySet := token.NewHMACKeySet("test-secret")
+	denylist := token.NewDenylist(cache.NewMemoryCacheable(10))
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+
+	ok := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/jobs", ok, JWTProtection(keySet, denylist), RoleAuthorization("client", "admin"))
+	// without JWTProtection nothing puts the claims in the context
+	e.GET("/unprotected", ok, RoleAuthorization("client"))
+	e.GET("/foreign-claims", ok, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"role": "client"}))
+			return next(c)
+		}
+	}, RoleAuthorization("client"))
+
+	bearer := func(role string) string {
+		accessToken, err := token.NewTokenUseCase(keySet, time.Minute, time.Hour).GenerateAccessToken(token.JwtCustomClaims{ID: uuid.New(), Role: role})
+		if err != nil {
+			t.Fatalf("GenerateAccessToken() error = %v", err)
+		}
+		return "Bearer " + accessToken
+	}
+
+	for _, tt := range []struct {
+		name          string
+		path          string
+		authorization string
+		want          int
+	}{
+		{"allowed role", "/jobs", bearer("client"), http.StatusOK},
+		{"another allowed role", "/jobs", bearer("admin"), http.StatusOK},
+		{"denied role", "/jobs", bearer("applicant"), http.StatusForbidden},
+		{"no role", "/jobs", bearer(""), http.StatusForbidden},
+		{"no token", "/jobs", "", http.StatusUnauthorized},
+		{"missing claims", "/unprotected", "", http.StatusUnauthorized},
+		{"claims of another type", "/foreign-claims", "", http.StatusUnauthorized},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("GET %s = %d, want %d: %s", tt.path, rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
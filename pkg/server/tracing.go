@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/DavidAfdal/workfinder/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/DavidAfdal/workfinder/pkg/server"
+
+// Tracing records a span for every request, continuing the trace of the caller when the request carries a
+// traceparent header. The span is put in the request context, database and Redis spans become its children
+// and log records carry its trace id.
+func Tracing() echo.MiddlewareFunc {
+	tracer := otel.GetTracerProvider().Tracer(instrumentationName)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+			// named after the route template, not the path, so /jobs/1 and /jobs/2 are the same operation
+			name := req.Method
+			if route := c.Path(); route != "" {
+				name += " " + route
+			}
+
+			ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(req.Method),
+				semconv.HTTPRoute(c.Path()),
+				semconv.URLPath(req.URL.Path),
+				semconv.ClientAddress(c.RealIP()),
+				attribute.String("request.id", logger.RequestID(req.Context())),
+			))
+			defer span.End()
+
+			c.SetRequest(req.WithContext(ctx))
+
+			err := next(c)
+			if err != nil {
+				span.RecordError(err)
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Use(Tracing())
+
+	var inHandler trace.SpanContext
+	e.GET("/jobs/:id", func(c echo.Context) error {
+		inHandler = trace.SpanContextFromContext(c.Request().Context())
+		if c.Param("id") == "broken" {
+			return errors.New("connection reset")
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/jobs/broken", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want one per request", len(spans))
+	}
+
+	traced := spans[0]
+	if traced.Name() != "GET /jobs/:id" || traced.SpanKind() != trace.SpanKindServer {
+		t.Fatalf("span = %q of kind %v, want a server span named after the route", traced.Name(), traced.SpanKind())
+	}
+	if traced.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || traced.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("span trace = %s, parent = %s, want the trace of the traceparent header", traced.SpanContext().TraceID(), traced.Parent().SpanID())
+	}
+
+	if failed := spans[1]; failed.Status().Code != codes.Error || failed.SpanContext().SpanID() != inHandler.SpanID() {
+		t.Fatalf("status = %v, want an error status on the span seen by the handler", failed.Status().Code)
+	}
+}
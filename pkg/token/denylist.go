@@ -0,0 +1,47 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DavidAfdal/workfinder/pkg/cache"
+)
+
+// Denylist keeps the jti of access tokens that were revoked before they expired.
+type Denylist interface {
+	Add(ctx context.Context, jti string, expire time.Duration) error
+	Contains(ctx context.Context, jti string) bool
+}
+
+type denylist struct {
+	cache cache.Cacheable
+}
+
+func NewDenylist(cache cache.Cacheable) Denylist {
+	return &denylist{cache: cache}
+}
+
+func (d *denylist) Add(ctx context.Context, jti string, expire time.Duration) error {
+	// an expired token is already rejected by the signature check
+	if jti == "" || expire <= 0 {
+		return nil
+	}
+
+	return d.cache.Set(ctx, denylistKey(jti), "1", expire, nil)
+}
+
+func (d *denylist) Contains(ctx context.Context, jti string) bool {
+	if jti == "" {
+		return false
+	}
+
+	// when the cache can't be reached the token is let through, its short lifetime bounds the exposure
+	_, err := d.cache.Get(ctx, denylistKey(jti))
+
+	return err == nil
+}
+
+func denylistKey(jti string) string {
+	return fmt.Sprintf("denylist_%s", jti)
+}
@@ -0,0 +1,247 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is one entry of a KeySet. A key without PrivateKey can only verify tokens, that is how a retired key
+// stays around until the last token it signed has expired. Symmetric keys only hold Secret, which both signs
+// and verifies and must never be published.
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	Secret     []byte
+}
+
+// signingKey is what jwt signs with, nil for a verify only key.
+func (k *Key) signingKey() interface{} {
+	if k.Secret != nil {
+		return k.Secret
+	}
+	if k.PrivateKey != nil {
+		return k.PrivateKey
+	}
+	return nil
+}
+
+// verifyingKey is what jwt verifies with.
+func (k *Key) verifyingKey() interface{} {
+	if k.Secret != nil {
+		return k.Secret
+	}
+	return k.PublicKey
+}
+
+// KeySet signs access tokens with its primary key and verifies them with any key it holds.
+type KeySet interface {
+	SigningKey() *Key
+	Keyfunc(t *jwt.Token) (interface{}, error)
+	JWKS() JWKS
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type keySet struct {
+	primary *Key
+	keys    map[string]*Key
+}
+
+// LoadKeySet reads every *.pem file in dir, the file name without extension becomes the kid.
+// Private keys may be RSA (RS256) or Ed25519 (EdDSA), public keys are loaded as verify only.
+//
+// To rotate, drop the new key in dir and point primaryKeyID at it. Keep the old private key until its
+// tokens are trusted everywhere, then replace it with its public key until the last access token expires.
+func LoadKeySet(dir string, primaryKeyID string) (KeySet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &keySet{keys: make(map[string]*Key)}
+
+	for _, file := range files {
+		key, err := loadKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwt key %s: %w", filepath.Base(file), err)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	primary, ok := ks.keys[primaryKeyID]
+	if !ok {
+		return nil, fmt.Errorf("jwt signing key %q not found in %s", primaryKeyID, dir)
+	}
+	if primary.PrivateKey == nil {
+		return nil, fmt.Errorf("jwt signing key %q has no private key", primaryKeyID)
+	}
+	ks.primary = primary
+
+	return ks, nil
+}
+
+func (ks *keySet) SigningKey() *Key {
+	return ks.primary
+}
+
+func (ks *keySet) Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown jwt key id %q", kid)
+	}
+
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected jwt signing method %s", t.Method.Alg())
+	}
+
+	return key.verifyingKey(), nil
+}
+
+// JWKS publishes the public half of every asymmetric key, symmetric keys are skipped.
+func (ks *keySet) JWKS() JWKS {
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(kids))}
+
+	for _, kid := range kids {
+		key := ks.keys[kid]
+		if key.Secret != nil {
+			continue
+		}
+
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+func loadKey(file string) (*Key, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.PrivateKey = privateKey
+	case "PRIVATE KEY":
+		privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key")
+		}
+		key.PrivateKey = signer
+	case "PUBLIC KEY":
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.PublicKey = publicKey
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	if key.PrivateKey != nil {
+		key.PublicKey = key.PrivateKey.Public()
+	}
+
+	switch key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+
+	return key, nil
+}
+
+type hmacKeySet struct {
+	key *Key
+}
+
+// NewHMACKeySet keeps the HS256 shared secret working when no key directory is configured.
+// The secret is never published, so its JWKS is empty.
+func NewHMACKeySet(secretKey string) KeySet {
+	return &hmacKeySet{key: &Key{Method: jwt.SigningMethodHS256, Secret: []byte(secretKey)}}
+}
+
+func (ks *hmacKeySet) SigningKey() *Key {
+	return ks.key
+}
+
+func (ks *hmacKeySet) Keyfunc(t *jwt.Token) (interface{}, error) {
+	if t.Method.Alg() != ks.key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected jwt signing method %s", t.Method.Alg())
+	}
+
+	return ks.key.verifyingKey(), nil
+}
+
+func (ks *hmacKeySet) JWKS() JWKS {
+	return JWKS{Keys: make([]JWK, 0)}
+}
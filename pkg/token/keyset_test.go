@@ -0,0 +1,125 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func writePEM(t *testing.T, dir string, name string, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, name+".pem"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "2024-01", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	writePEM(t, dir, "2024-06", "PRIVATE KEY", edDER)
+
+	oldKeySet, err := LoadKeySet(dir, "2024-01")
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+	newKeySet, err := LoadKeySet(dir, "2024-06")
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+
+	oldToken, err := NewTokenUseCase(oldKeySet, time.Minute, time.Hour).GenerateAccessToken(JwtCustomClaims{ID: uuid.New()})
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+	newToken, err := NewTokenUseCase(newKeySet, time.Minute, time.Hour).GenerateAccessToken(JwtCustomClaims{ID: uuid.New()})
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+
+	for name, signed := range map[string]string{"RS256": oldToken, "EdDSA": newToken} {
+		parsed, err := jwt.ParseWithClaims(signed, new(JwtCustomClaims), newKeySet.Keyfunc)
+		if err != nil || !parsed.Valid {
+			t.Fatalf("%s token rejected after rotation: %v", name, err)
+		}
+		if parsed.Method.Alg() != name {
+			t.Fatalf("token alg = %s, want %s", parsed.Method.Alg(), name)
+		}
+	}
+
+	if keys := newKeySet.JWKS().Keys; len(keys) != 2 || keys[0].KeyType != "RSA" || keys[1].KeyType != "OKP" {
+		t.Fatalf("JWKS() = %+v, want one RSA and one OKP key", keys)
+	}
+}
+
+func TestKeySetRejectsAlgorithmConfusion(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	edDER, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	writePEM(t, dir, "main", "PRIVATE KEY", edDER)
+
+	keySet, err := LoadKeySet(dir, "main")
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, JwtCustomClaims{ID: uuid.New()})
+	forged.Header["kid"] = "main"
+	signed, _ := forged.SignedString([]byte(edKey.Public().(ed25519.PublicKey)))
+
+	if _, err := jwt.ParseWithClaims(signed, new(JwtCustomClaims), keySet.Keyfunc); err == nil {
+		t.Fatalf("HS256 token signed with the public key was accepted")
+	}
+}
+
+func TestHMACKeySet(t *testing.T) {
+	hmacKeys := NewHMACKeySet("test-secret")
+
+	if key := hmacKeys.SigningKey(); key.PublicKey != nil || string(key.Secret) != "test-secret" {
+		t.Fatalf("SigningKey() = %+v, want the secret kept apart from the public key", key)
+	}
+
+	signed, err := NewTokenUseCase(hmacKeys, time.Minute, time.Hour).GenerateAccessToken(JwtCustomClaims{ID: uuid.New()})
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+	if _, err := jwt.ParseWithClaims(signed, new(JwtCustomClaims), hmacKeys.Keyfunc); err != nil {
+		t.Fatalf("ParseWithClaims() error = %v", err)
+	}
+
+	if keys := hmacKeys.JWKS().Keys; len(keys) != 0 {
+		t.Fatalf("JWKS() = %+v, want the secret unpublished", keys)
+	}
+
+	// a symmetric key next to asymmetric ones is never published either
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	mixed := &keySet{keys: map[string]*Key{
+		"hs":   {ID: "hs", Method: jwt.SigningMethodHS256, Secret: []byte("test-secret")},
+		"main": {ID: "main", Method: jwt.SigningMethodEdDSA, PrivateKey: edKey, PublicKey: edKey.Public()},
+	}}
+	if keys := mixed.JWKS().Keys; len(keys) != 1 || keys[0].KeyID != "main" {
+		t.Fatalf("JWKS() = %+v, want only the Ed25519 key", keys)
+	}
+}
@@ -1,35 +1,78 @@
 package token
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
 type TokenUseCase interface {
 	GenerateAccessToken(claims JwtCustomClaims) (string, error)
+	GenerateRefreshToken() (*RefreshToken, error)
 }
 type tokenUseCase struct {
-	secretKey string
+	keySet KeySet
+	accessTokenExpire time.Duration
+	refreshTokenExpire time.Duration
 }
 
 type JwtCustomClaims struct {
 	ID     uuid.UUID `json:"id"`
 	Email  string `json:"email"`
-	Address string `json:"address"`
-	PhoneNumber   string `json:"phone_number"`
+	Role   string `json:"role"`
 	jwt.RegisteredClaims
 }
 
+// RefreshToken is only ever handed to the client as Token, the database keeps Hash.
+type RefreshToken struct {
+	Token     string
+	Hash      string
+	ExpiresAt time.Time
+}
 
-func NewTokenUseCase(secretKey string) TokenUseCase {
-	return &tokenUseCase{secretKey: secretKey}
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+
+func NewTokenUseCase(keySet KeySet, accessTokenExpire time.Duration, refreshTokenExpire time.Duration) TokenUseCase {
+	return &tokenUseCase{keySet: keySet, accessTokenExpire: accessTokenExpire, refreshTokenExpire: refreshTokenExpire}
 }
 
 
 func (t *tokenUseCase) GenerateAccessToken(claims JwtCustomClaims) (string,error) {
-	plainToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	now := time.Now()
+
+	if claims.RegisteredClaims.ID == "" {
+		claims.RegisteredClaims.ID = uuid.NewString()
+	}
+	if claims.IssuedAt == nil {
+		claims.IssuedAt = jwt.NewNumericDate(now)
+	}
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(t.accessTokenExpire))
+	}
+
+	key := t.keySet.SigningKey()
+
+	plainToken := jwt.NewWithClaims(key.Method, claims)
+
+	signingKey := key.signingKey()
+	if signingKey == nil {
+		return "", fmt.Errorf("jwt key %q can only verify tokens", key.ID)
+	}
+	if key.ID != "" {
+		plainToken.Header["kid"] = key.ID
+	}
 
-	encodedToken, err := plainToken.SignedString([]byte(t.secretKey))
+	encodedToken, err := plainToken.SignedString(signingKey)
 
 	if err != nil {
 		return "", err
@@ -37,3 +80,34 @@ func (t *tokenUseCase) GenerateAccessToken(claims JwtCustomClaims) (string,error
 
 	return encodedToken, nil
 }
+
+func (t *tokenUseCase) GenerateRefreshToken() (*RefreshToken, error) {
+	plainToken, err := GenerateOpaqueToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &RefreshToken{
+		Token:     plainToken,
+		Hash:      HashToken(plainToken),
+		ExpiresAt: time.Now().Add(t.refreshTokenExpire),
+	}, nil
+}
+
+// GenerateOpaqueToken returns 256 random bits encoded for use in URLs and request bodies.
+func GenerateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex encoded SHA-256 of an opaque token, tokens are random so a fast hash is enough.
+func HashToken(plainToken string) string {
+	sum := sha256.Sum256([]byte(plainToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type job struct {
+	ID         int
+	Applicants []applicant
+}
+
+type applicant struct {
+	ID    int
+	JobID int
+}
+
+func TestGormPluginRecordsQueries(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	defer sqlDB.Close()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	if err := db.Use(NewGormPlugin(provider)); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "jobs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "applicants"`).WillReturnRows(sqlmock.NewRows([]string{"id", "job_id"}).AddRow(7, 1))
+	mock.ExpectQuery(`SELECT \* FROM "jobs"`).WillReturnError(errors.New("connection reset"))
+
+	ctx, request := provider.Tracer("test").Start(context.Background(), "GET /jobs/:id")
+
+	var found job
+	if err := db.WithContext(ctx).Preload("Applicants").First(&found, 1).Error; err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	db.WithContext(ctx).First(&found, 2)
+	request.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("recorded %d spans, want 3 queries and the request", len(spans))
+	}
+
+	// the preload finishes first, it runs inside the query of the job
+	for i, want := range []string{"query applicants", "query jobs", "query jobs"} {
+		span := spans[i]
+		if span.Name() != want || span.Parent().SpanID() != request.SpanContext().SpanID() {
+			t.Fatalf("span %d = %q with parent %s, want %q under the request", i, span.Name(), span.Parent().SpanID(), want)
+		}
+	}
+
+	if status := spans[1].Status().Code; status != codes.Unset {
+		t.Fatalf("status of the successful query = %v, want unset", status)
+	}
+	if status := spans[2].Status().Code; status != codes.Error {
+		t.Fatalf("status of the failed query = %v, want error", status)
+	}
+
+	var query string
+	for _, attr := range spans[1].Attributes() {
+		if attr.Key == "db.query.text" {
+			query = attr.Value.AsString()
+		}
+	}
+	if query != `SELECT * FROM "jobs" WHERE "jobs"."id" = $1 ORDER BY "jobs"."id" LIMIT $2` {
+		t.Fatalf("db.query.text = %q, want the SQL with its placeholders", query)
+	}
+}
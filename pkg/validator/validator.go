@@ -0,0 +1,137 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	MinPasswordLength = 8
+	// bcrypt ignores everything after 72 bytes
+	MaxPasswordLength = 72
+)
+
+var ErrValidation = apperror.Validation("validation_failed", "request validation failed")
+
+var (
+	phoneNumberPattern = regexp.MustCompile(`^\+?[0-9]{8,14}$`)
+	oneOfPattern       = regexp.MustCompile(`'[^']*'|\S+`)
+)
+
+// Validator implements echo.Validator, every rejected field is reported by the name the client sent it with.
+type Validator struct {
+	validate *validator.Validate
+}
+
+func NewValidator() *Validator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	validate.RegisterTagNameFunc(fieldName)
+	validate.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return IsStrongPassword(fl.Field().String())
+	})
+	validate.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		return phoneNumberPattern.MatchString(fl.Field().String())
+	})
+
+	return &Validator{validate: validate}
+}
+
+func (v *Validator) Validate(i interface{}) error {
+	err := v.validate.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	fields := make([]apperror.FieldError, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		fields = append(fields, apperror.FieldError{Field: fieldErr.Field(), Message: message(fieldErr)})
+	}
+
+	return ErrValidation.WithFields(fields)
+}
+
+// IsStrongPassword requires MinPasswordLength to MaxPasswordLength bytes with at least one letter and one digit.
+func IsStrongPassword(password string) bool {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return false
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		hasLetter = hasLetter || unicode.IsLetter(r)
+		hasDigit = hasDigit || unicode.IsDigit(r)
+	}
+
+	return hasLetter && hasDigit
+}
+
+// fieldName picks the json, query or param tag so errors match the request the client sent.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "query", "param"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func message(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "uuid", "uuid4":
+		return "must be a valid id"
+	case "password":
+		return fmt.Sprintf("must be %d to %d characters and contain a letter and a number", MinPasswordLength, MaxPasswordLength)
+	case "phone":
+		return "must be a valid phone number"
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.Join(oneOfValues(fieldErr.Param()), ", "))
+	case "max":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fieldErr.Param())
+	case "min":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fieldErr.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fieldErr.Param())
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", fieldErr.Param())
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s", fieldErr.Param())
+	default:
+		return fmt.Sprintf("failed on the %s rule", fieldErr.Tag())
+	}
+}
+
+// oneOfValues splits a oneof param the way the validator does, 'Full Time' is a single value.
+func oneOfValues(param string) []string {
+	values := oneOfPattern.FindAllString(param, -1)
+	for i, value := range values {
+		values[i] = strings.Trim(value, "'")
+	}
+	return values
+}
@@ -0,0 +1,81 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DavidAfdal/workfinder/pkg/apperror"
+)
+
+type signupRequest struct {
+	ID       string  `param:"id" validate:"required,uuid"`
+	Email    string  `json:"email" validate:"required,email,max=255"`
+	Password string  `json:"password" validate:"required,password"`
+	Phone    string  `json:"phone_number" validate:"omitempty,phone"`
+	Status   string  `json:"status" validate:"required,oneof='Full Time' Contract"`
+	Salary   float64 `json:"salary" validate:"gt=0"`
+	Limit    int     `query:"limit" validate:"gte=0,lte=100"`
+}
+
+func TestValidatorReportsEveryField(t *testing.T) {
+	v := NewValidator()
+
+	valid := signupRequest{
+		ID:       "5b8e3c1e-8f7a-4c55-9d6e-0f3b2a1c4d5e",
+		Email:    "jane@workfinder.id",
+		Password: "secret123",
+		Phone:    "+6281234567890",
+		Status:   "Full Time",
+		Salary:   1,
+		Limit:    20,
+	}
+
+	if err := v.Validate(&valid); err != nil {
+		t.Fatalf("Validate() valid request error = %v", err)
+	}
+
+	err := v.Validate(&signupRequest{ID: "1", Email: "jane", Password: "secret", Phone: "call me", Status: "Remote", Limit: 101})
+
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("Validate() error = %v, want %v", err, ErrValidation)
+	}
+
+	appErr, _ := apperror.As(err)
+
+	want := map[string]string{
+		"id":           "must be a valid id",
+		"email":        "must be a valid email address",
+		"password":     "must be 8 to 72 characters and contain a letter and a number",
+		"phone_number": "must be a valid phone number",
+		"status":       "must be one of: Full Time, Contract",
+		"salary":       "must be greater than 0",
+		"limit":        "must be less than or equal to 100",
+	}
+
+	if len(appErr.Fields) != len(want) {
+		t.Fatalf("fields = %+v, want %d fields", appErr.Fields, len(want))
+	}
+
+	for _, field := range appErr.Fields {
+		if want[field.Field] != field.Message {
+			t.Errorf("field %s message = %q, want %q", field.Field, field.Message, want[field.Field])
+		}
+	}
+}
+
+func TestIsStrongPassword(t *testing.T) {
+	tests := map[string]bool{
+		"secret123":                             true,
+		"secret":                                false,
+		"12345678":                              false,
+		"password":                              false,
+		strings.Repeat("a1", MaxPasswordLength): false,
+	}
+
+	for password, want := range tests {
+		if got := IsStrongPassword(password); got != want {
+			t.Errorf("IsStrongPassword(%q) = %v, want %v", password, got, want)
+		}
+	}
+}